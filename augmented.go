@@ -0,0 +1,233 @@
+package skiplist
+
+import (
+	"iter"
+	"math"
+	"unsafe"
+)
+
+// Aggregator is an associative operation with an identity, a monoid, used to aggregate values of a key range.
+// Lift converts a single value, and Combine must be associative, Combine(Identity, a) == a.
+type Aggregator[V, A any] struct {
+	Identity A
+	Lift     func(value V) A
+	Combine  func(a, b A) A
+}
+
+// SumAggregator returns an Aggregator of the sum of values.
+func SumAggregator[V Numbers]() Aggregator[V, V] {
+	return Aggregator[V, V]{
+		Lift:    func(value V) V { return value },
+		Combine: func(a, b V) V { return a + b },
+	}
+}
+
+// MinAggregator returns an Aggregator of the minimum value.
+// The aggregate of an empty range is the largest value of V, +Inf for floats.
+func MinAggregator[V Numbers]() Aggregator[V, V] {
+	_, largest := numberLimits[V]()
+	return Aggregator[V, V]{
+		Identity: largest,
+		Lift:     func(value V) V { return value },
+		Combine:  func(a, b V) V { return min(a, b) },
+	}
+}
+
+// MaxAggregator returns an Aggregator of the maximum value.
+// The aggregate of an empty range is the smallest value of V, -Inf for floats.
+func MaxAggregator[V Numbers]() Aggregator[V, V] {
+	smallest, _ := numberLimits[V]()
+	return Aggregator[V, V]{
+		Identity: smallest,
+		Lift:     func(value V) V { return value },
+		Combine:  func(a, b V) V { return max(a, b) },
+	}
+}
+
+// numberLimits returns the smallest and the largest value of T, infinities for floats.
+func numberLimits[T Numbers]() (smallest, largest T) {
+	switch {
+	case isFloat[T]():
+		return T(math.Inf(-1)), T(math.Inf(1))
+	case isSigned[T]():
+		largest = T(uint64(1)<<(unsafe.Sizeof(smallest)*8-1) - 1)
+		return -largest - 1, largest
+	default:
+		return 0, T(^uint64(0) >> (64 - unsafe.Sizeof(smallest)*8))
+	}
+}
+
+// Augmented is a skip list which keeps aggregates of values, to aggregate any key range in O(log(N)).
+// Each node keeps, for each level of its tower, the aggregate of the values from the node up to its next node on the level.
+//
+// Unlike SkipList, it doesn't expose Element because a value changed without the list would break the aggregates.
+// Augmented is not safe for concurrent use.
+type Augmented[K, V, A any] struct {
+	towers[K, augmentedData[V, A]]
+	aggregator Aggregator[V, A]
+	length     int
+}
+
+type augmentedData[V, A any] struct {
+	value V
+	agg   []A // agg[i] aggregates values from this node to next[i], next[i] excluded.
+}
+
+// NewAugmented creates a new augmented skip list with comparable to compare keys and aggregator to aggregate values.
+// Only WithMaxLevel and WithProbability are used, other options are ignored.
+func NewAugmented[K, V, A any](comparable Comparable[K], aggregator Aggregator[V, A], options ...Option) *Augmented[K, V, A] {
+	list := &Augmented[K, V, A]{
+		towers:     newTowers[K, augmentedData[V, A]](comparable, newOptions(options)),
+		aggregator: aggregator,
+	}
+	list.head.data.agg = make([]A, list.maxLevel())
+	for i := range list.head.data.agg {
+		list.head.data.agg[i] = aggregator.Identity
+	}
+	return list
+}
+
+// Len returns element count in this list.
+//
+// The complexity is O(1).
+func (list *Augmented[K, V, A]) Len() int {
+	return list.length
+}
+
+// Set sets value for the key.
+// If the key exists, updates element's value.
+//
+// The complexity is O(log(N)).
+func (list *Augmented[K, V, A]) Set(key K, value V) {
+	prevs := list.search(key)
+	if node := prevs[0].next[0]; node != nil && list.comparable(node.key, key) == 0 {
+		node.data.value = value
+		// only the aggregates covering node change.
+		for i := range list.maxLevel() {
+			if i < len(node.next) {
+				list.update(node, i)
+			} else {
+				list.update(prevs[i], i)
+			}
+		}
+		return
+	}
+
+	node := list.newTower(key)
+	node.data = augmentedData[V, A]{value: value, agg: make([]A, len(node.next))}
+	list.link(prevs, node)
+	for i := range list.maxLevel() {
+		if i < len(node.next) {
+			list.update(node, i)
+		}
+		list.update(prevs[i], i)
+	}
+	list.length++
+}
+
+// GetValue returns value of the element with the key.
+//
+// The complexity is O(log(N)).
+func (list *Augmented[K, V, A]) GetValue(key K) (val V, ok bool) {
+	if node := list.find(key, false); node != nil && list.comparable(node.key, key) == 0 {
+		return node.data.value, true
+	}
+	return
+}
+
+// Remove removes the element with the key.
+// Returns the removed value, ok is false if the key is not found.
+//
+// The complexity is O(log(N)).
+func (list *Augmented[K, V, A]) Remove(key K) (val V, ok bool) {
+	prevs := list.search(key)
+	node := prevs[0].next[0]
+	if node == nil || list.comparable(node.key, key) != 0 {
+		return
+	}
+	list.unlink(prevs, node)
+	for i := range list.maxLevel() {
+		list.update(prevs[i], i)
+	}
+	list.length--
+	return node.data.value, true
+}
+
+// Aggregate returns the aggregate of values with key between from and to, in key order.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// If the range is empty, returns the identity of the aggregator.
+//
+// The complexity is O(log(N)).
+func (list *Augmented[K, V, A]) Aggregate(from, to K, options ...RangeOption) A {
+	option := newRangeOptions(options)
+	in := func(node *tower[K, augmentedData[V, A]]) bool {
+		c := list.comparable(node.key, to)
+		return c < 0 || c == 0 && !option.exclusiveTo
+	}
+	acc := list.aggregator.Identity
+	for node := list.find(from, option.exclusiveFrom); node != nil && in(node); {
+		// take the highest level which ends within the range.
+		i := len(node.next) - 1
+		for i > 0 && (node.next[i] == nil || !in(node.next[i])) {
+			i--
+		}
+		acc = list.aggregator.Combine(acc, node.data.agg[i])
+		node = node.next[i]
+	}
+	return acc
+}
+
+// All returns an iterator over key-value pairs from front to back.
+func (list *Augmented[K, V, A]) All() iter.Seq2[K, V] {
+	return list.iterate(list.head.next[0])
+}
+
+// Ascend returns an iterator over key-value pairs greater or equal to from, in ascending order.
+func (list *Augmented[K, V, A]) Ascend(from K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		list.iterate(list.find(from, false))(yield)
+	}
+}
+
+// Keys returns list of keys
+func (list *Augmented[K, V, A]) Keys() (keys []K) {
+	for k := range list.All() {
+		keys = append(keys, k)
+	}
+	return
+}
+
+// Values returns list of values
+func (list *Augmented[K, V, A]) Values() (values []V) {
+	for _, v := range list.All() {
+		values = append(values, v)
+	}
+	return
+}
+
+func (list *Augmented[K, V, A]) iterate(start *tower[K, augmentedData[V, A]]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for node := start; node != nil; node = node.next[0] {
+			if !yield(node.key, node.data.value) {
+				return
+			}
+		}
+	}
+}
+
+// update recomputes the aggregate of node on level i from the aggregates of level i-1.
+func (list *Augmented[K, V, A]) update(node *tower[K, augmentedData[V, A]], i int) {
+	if i == 0 {
+		if node == list.head {
+			node.data.agg[0] = list.aggregator.Identity
+		} else {
+			node.data.agg[0] = list.aggregator.Lift(node.data.value)
+		}
+		return
+	}
+	acc := node.data.agg[i-1]
+	for next := node.next[i-1]; next != node.next[i]; next = next.next[i-1] {
+		acc = list.aggregator.Combine(acc, next.data.agg[i-1])
+	}
+	node.data.agg[i] = acc
+}
@@ -0,0 +1,100 @@
+package skiplist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestAugmented(t *testing.T) {
+	a := assert.New(t)
+	sum := NewAugmented[int, int](NumberComparator[int], SumAggregator[int](), WithMaxLevel(8), WithProbability(0.5))
+	minimum := NewAugmented[int, int](NumberComparator[int], MinAggregator[int]())
+	maximum := NewAugmented[int, int](NumberComparator[int], MaxAggregator[int]())
+	values := map[int]int{}
+	for i := 0; i < 2000; i++ {
+		key, value := rand.Intn(500), rand.Intn(1000)-500
+		if rand.Intn(4) == 0 {
+			_, ok := sum.Remove(key)
+			_, exists := values[key]
+			a.Equal(exists, ok)
+			minimum.Remove(key)
+			maximum.Remove(key)
+			delete(values, key)
+		} else {
+			sum.Set(key, value)
+			minimum.Set(key, value)
+			maximum.Set(key, value)
+			values[key] = value
+		}
+	}
+	a.Equal(len(values), sum.Len())
+	for k, v := range sum.All() {
+		a.Equal(values[k], v)
+	}
+
+	for i := 0; i < 300; i++ {
+		from := rand.Intn(520) - 10
+		to := from + rand.Intn(200)
+		expectedSum, expectedMin, expectedMax := 0, math.MaxInt, math.MinInt
+		exclusiveSum := 0
+		for k, v := range values {
+			if k >= from && k <= to {
+				expectedSum += v
+				expectedMin = min(expectedMin, v)
+				expectedMax = max(expectedMax, v)
+				if k != from && k != to {
+					exclusiveSum += v
+				}
+			}
+		}
+		a.Equal(expectedSum, sum.Aggregate(from, to))
+		a.Equal(expectedMin, minimum.Aggregate(from, to))
+		a.Equal(expectedMax, maximum.Aggregate(from, to))
+		a.Equal(exclusiveSum, sum.Aggregate(from, to, WithExclusiveFrom(), WithExclusiveTo()))
+	}
+	a.Equal(0, sum.Aggregate(10, 0))
+}
+
+func TestAugmentedOrder(t *testing.T) {
+	a := assert.New(t)
+	// concatenation is not commutative, so the result shows the order of values.
+	concat := Aggregator[string, string]{
+		Lift:    func(value string) string { return value },
+		Combine: func(a, b string) string { return a + b },
+	}
+	list := NewAugmented[int, string](NumberComparator[int], concat)
+	for _, i := range rand.Perm(26) {
+		list.Set(i, string(rune('a'+i)))
+	}
+	a.Equal("abcdefghijklmnopqrstuvwxyz", list.Aggregate(0, 25))
+	a.Equal("fghij", list.Aggregate(5, 9))
+	list.Set(7, "H")
+	list.Remove(8)
+	a.Equal("fgHj", list.Aggregate(5, 9))
+	v, ok := list.GetValue(7)
+	a.True(ok)
+	a.Equal("H", v)
+	var keys []int
+	for k := range list.Ascend(23) {
+		keys = append(keys, k)
+	}
+	a.Equal([]int{23, 24, 25}, keys)
+}
+
+func TestNumberLimits(t *testing.T) {
+	a := assert.New(t)
+	lo8, hi8 := numberLimits[int8]()
+	a.Equal(int8(math.MinInt8), lo8)
+	a.Equal(int8(math.MaxInt8), hi8)
+	lo, hi := numberLimits[uint16]()
+	a.Equal(uint16(0), lo)
+	a.Equal(uint16(math.MaxUint16), hi)
+	lof, hif := numberLimits[float32]()
+	a.True(math.IsInf(float64(lof), -1))
+	a.True(math.IsInf(float64(hif), 1))
+	lo64, hi64 := numberLimits[int64]()
+	a.Equal(int64(math.MinInt64), lo64)
+	a.Equal(int64(math.MaxInt64), hi64)
+}
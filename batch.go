@@ -0,0 +1,183 @@
+package skiplist
+
+import "fmt"
+
+// BatchOp is a single operation of a Batch.
+type BatchOp[K, V any] struct {
+	Key    K
+	Value  V
+	Remove bool
+}
+
+// BatchValidator is called for each operation of a batch right before it is applied.
+// old and exists describe the current value of the key, including earlier operations of the batch.
+// Returning an error rejects the operation, and the whole batch is rolled back.
+type BatchValidator[K, V any] func(op BatchOp[K, V], old V, exists bool) error
+
+// Batch collects Set and Remove operations to apply them atomically with Apply.
+type Batch[K, V any] struct {
+	ops      []BatchOp[K, V]
+	validate BatchValidator[K, V]
+	undo     []batchUndo[K, V]
+}
+
+// batchUndo records how to revert an applied operation.
+type batchUndo[K, V any] struct {
+	elem       *Element[K, V] // Updated, inserted or removed element, nil if only a tombstone was added.
+	key        K
+	value      V
+	expireAt   int64
+	inserted   bool
+	removed    bool
+	tombstoned bool // The key had a tombstone before it was inserted or removed.
+}
+
+// NewBatch creates an empty batch. validate may be nil.
+func NewBatch[K, V any](validate BatchValidator[K, V]) *Batch[K, V] {
+	return &Batch[K, V]{validate: validate}
+}
+
+// Set adds an operation setting value for the key.
+func (batch *Batch[K, V]) Set(key K, value V) *Batch[K, V] {
+	batch.ops = append(batch.ops, BatchOp[K, V]{Key: key, Value: value})
+	return batch
+}
+
+// Remove adds an operation removing the key.
+func (batch *Batch[K, V]) Remove(key K) *Batch[K, V] {
+	batch.ops = append(batch.ops, BatchOp[K, V]{Key: key, Remove: true})
+	return batch
+}
+
+// Len returns the number of operations in the batch.
+func (batch *Batch[K, V]) Len() int {
+	return len(batch.ops)
+}
+
+// Reset removes all operations from the batch, keeping the validator.
+func (batch *Batch[K, V]) Reset() {
+	batch.ops = batch.ops[:0]
+}
+
+// Apply applies all operations of batch in order.
+// If the validator of batch rejects an operation, all applied operations are rolled back
+// and the error is returned.
+// Operations sorted by key reuse the previous search as a finger, so sorted batches are faster.
+//
+// The complexity is O(M*log(N)), M is the number of operations.
+func (list *skipListUnSafe[K, V]) Apply(batch *Batch[K, V]) error {
+	// without a validator nothing can be rolled back, so there is no need to record changes.
+	record := batch.validate != nil
+	undo := batch.undo[:0]
+	defer func() {
+		clear(undo)
+		batch.undo = undo[:0]
+	}()
+	finger := false
+	var last K
+	for i, op := range batch.ops {
+		var prevs []*elementHeader[K, V]
+		if finger && list.comparable(op.Key, last) >= 0 {
+			prevs = list.getPrevElementNodesFrom(op.Key)
+		} else {
+			prevs = list.getPrevElementNodes(op.Key)
+		}
+		finger, last = true, op.Key
+
+		var old V
+		elem := list.dropExpired(prevs, op.Key)
+		exists := elem != nil && list.comparable(elem.key, op.Key) == 0
+		if exists {
+			old = elem.Value
+		}
+		if batch.validate != nil {
+			if err := batch.validate(op, old, exists); err != nil {
+				list.rollback(undo)
+				return fmt.Errorf("skiplist: batch operation %v rejected: %w", i, err)
+			}
+		}
+
+		switch {
+		case op.Remove:
+			if exists {
+				if record {
+					// keep the element intact until the batch is applied, rollback links it again.
+					undo = append(undo, batchUndo[K, V]{elem: elem, removed: true, tombstoned: list.tombstoned(op.Key)})
+					list.tombstone(op.Key)
+					list.detachElement(prevs, elem)
+				} else {
+					list.unlinkElement(prevs, elem)
+				}
+			} else if list.tombstones != nil {
+				if record {
+					undo = append(undo, batchUndo[K, V]{key: op.Key, tombstoned: list.tombstoned(op.Key)})
+				}
+				list.tombstone(op.Key)
+			}
+		case exists && !list.duplicates:
+			if record {
+				undo = append(undo, batchUndo[K, V]{elem: elem, value: old, expireAt: elem.expireAt})
+			}
+			list.preserve(elem)
+			elem.Value = op.Value
+			elem.expireAt = 0
+			if !record {
+				list.touch(elem)
+			}
+		default:
+			if list.duplicates {
+				// insert after equal keys, the finger is past the key now.
+				prevs = list.searchPrevElementNodes(op.Key, true)
+				finger = false
+			}
+			tombstoned := record && list.tombstoned(op.Key)
+			elem = list.insertElement(prevs, op.Key, op.Value)
+			if record {
+				undo = append(undo, batchUndo[K, V]{elem: elem, inserted: true, tombstoned: tombstoned})
+			}
+		}
+	}
+	for _, u := range undo {
+		switch {
+		case u.removed:
+			list.pool.Put(u.elem)
+		case u.elem != nil && !u.inserted:
+			// updates are used once the batch is applied, so a rollback doesn't have to undo it.
+			list.touch(u.elem)
+		}
+	}
+	list.evict(nil)
+	return nil
+}
+
+// rollback reverts applied operations in reverse order.
+// Removed elements are linked again at their former positions, so pointers to them stay valid.
+func (list *skipListUnSafe[K, V]) rollback(undo []batchUndo[K, V]) {
+	for i := len(undo) - 1; i >= 0; i-- {
+		u := undo[i]
+		switch {
+		case u.elem == nil:
+			if !u.tombstoned {
+				list.tombstones.remove(u.key)
+			}
+		case u.inserted:
+			key := u.elem.key
+			list.discardElement(u.elem)
+			if u.tombstoned {
+				list.tombstone(key)
+			}
+		case u.removed:
+			list.relinkElement(u.elem)
+			if u.tombstoned {
+				list.tombstone(u.elem.key)
+			}
+		default:
+			list.preserve(u.elem)
+			u.elem.Value = u.value
+			u.elem.expireAt = u.expireAt
+			if u.expireAt != 0 {
+				list.expiry.schedule(u.elem)
+			}
+		}
+	}
+}
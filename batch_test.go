@@ -0,0 +1,113 @@
+package skiplist
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	a := assert.New(t)
+	for _, options := range [][]Option{nil, {WithMutex()}, {WithDuplicates()}} {
+		list := New[int, int](NumberComparator[int], options...)
+		expected := New[int, int](NumberComparator[int], options...)
+		for i := 0; i < 100; i++ {
+			list.Set(i*2, i)
+			expected.Set(i*2, i)
+		}
+		batch := NewBatch[int, int](nil)
+		for i := 0; i < 300; i++ {
+			k := rand.Intn(250)
+			if i > 150 {
+				// sorted tail exercises the finger.
+				k = i - 150
+			}
+			if rand.Intn(3) == 0 {
+				batch.Remove(k)
+				expected.Remove(k)
+			} else {
+				batch.Set(k, i)
+				expected.Set(k, i)
+			}
+		}
+		a.Equal(300, batch.Len())
+		a.NoError(list.Apply(batch))
+		a.Equal(expected.Keys(), list.Keys())
+		a.Equal(expected.Values(), list.Values())
+		assertSanity(a, list)
+		batch.Reset()
+		a.Equal(0, batch.Len())
+	}
+}
+
+func TestApplyRollback(t *testing.T) {
+	a := assert.New(t)
+	for _, options := range [][]Option{nil, {WithDuplicates()}, {WithDuplicates(), WithPool()}} {
+		list := New[int, int](NumberComparator[int], options...)
+		for i := 0; i < 100; i++ {
+			list.Set(i, i)
+		}
+		list.Set(50, -50)
+		list.Set(50, 5050)
+		keys, values := list.Keys(), list.Values()
+		var elems []*Element[int, int]
+		for elem := list.Front(); elem != nil; elem = elem.Next() {
+			elems = append(elems, elem)
+		}
+
+		errRejected := errors.New("rejected")
+		batch := NewBatch[int, int](func(op BatchOp[int, int], old int, exists bool) error {
+			if op.Key == 1000 {
+				return errRejected
+			}
+			return nil
+		})
+		batch.Set(5, 500).Remove(50).Remove(7).Set(200, 1).Remove(200).Set(50, 1).Set(50, 2).Remove(0)
+		batch.Set(1000, 0).Set(1001, 0)
+		err := list.Apply(batch)
+		a.ErrorIs(err, errRejected)
+		a.Equal(keys, list.Keys())
+		a.Equal(values, list.Values())
+		assertSanity(a, list)
+		// removed elements are restored in place, not as new elements.
+		for i, elem := range elems {
+			a.Same(elem, list.GetByRank(i))
+			a.Equal(i, list.Index(elem))
+		}
+	}
+
+	list := New[int, int](NumberComparator[int])
+	list.Set(1, 1)
+	var seen []bool
+	batch := NewBatch[int, int](func(op BatchOp[int, int], old int, exists bool) error {
+		seen = append(seen, exists)
+		return nil
+	})
+	a.NoError(list.Apply(batch.Set(1, 2).Set(2, 2).Remove(1).Set(1, 3)))
+	a.Equal([]bool{true, false, true, false}, seen)
+	a.Equal([]int{3, 2}, list.Values())
+}
+
+func BenchmarkApplySorted(b *testing.B) {
+	list := New[int, int](NumberComparator[int])
+	for i := 0; i < 100000; i++ {
+		list.Set(i*2, i)
+	}
+	batch := NewBatch[int, int](nil)
+	for i := 0; i < 1000; i++ {
+		batch.Set(i*7+1, i)
+	}
+	b.Run("Apply", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			_ = list.Apply(batch)
+		}
+	})
+	b.Run("Set", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for i := 0; i < 1000; i++ {
+				list.Set(i*7+1, i)
+			}
+		}
+	})
+}
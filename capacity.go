@@ -0,0 +1,205 @@
+package skiplist
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// EvictionPolicy decides which element is evicted when a list created WithCapacity is over its capacity.
+type EvictionPolicy int
+
+const (
+	// EvictSmallest evicts the front element, like RemoveFront.
+	EvictSmallest EvictionPolicy = iota
+	// EvictLargest evicts the back element, like RemoveBack.
+	EvictLargest
+	// EvictLRU evicts the least recently used element.
+	EvictLRU
+	// EvictLFU evicts the least frequently used element, the least recently used one among equal counts.
+	EvictLFU
+)
+
+// evictor is the state of a list created WithCapacity.
+// Elements are used when they are set, or found by Get, GetValue, MustGetValue, GetOrSet or Compute.
+type evictor[K, V any] struct {
+	limit   int
+	policy  EvictionPolicy
+	onEvict func(key K, value V)
+
+	// lock guards access tracking, which is also updated by reads.
+	lock    sync.Mutex
+	entries map[*Element[K, V]]*accessEntry[K, V]
+	heap    accessHeap[K, V]
+	tick    uint64
+}
+
+// accessEntry tracks the usage of an element for EvictLRU and EvictLFU.
+type accessEntry[K, V any] struct {
+	elem  *Element[K, V]
+	count uint64
+	tick  uint64
+	index int
+}
+
+// accessHeap orders entries by tick for EvictLRU, by count and then by tick for EvictLFU.
+type accessHeap[K, V any] struct {
+	entries []*accessEntry[K, V]
+	lfu     bool
+}
+
+func (h *accessHeap[K, V]) Len() int { return len(h.entries) }
+
+func (h *accessHeap[K, V]) Less(i, j int) bool {
+	a, b := h.entries[i], h.entries[j]
+	if h.lfu && a.count != b.count {
+		return a.count < b.count
+	}
+	return a.tick < b.tick
+}
+
+func (h *accessHeap[K, V]) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.entries[i].index = i
+	h.entries[j].index = j
+}
+
+func (h *accessHeap[K, V]) Push(x any) {
+	entry := x.(*accessEntry[K, V])
+	entry.index = len(h.entries)
+	h.entries = append(h.entries, entry)
+}
+
+func (h *accessHeap[K, V]) Pop() any {
+	last := h.entries[len(h.entries)-1]
+	h.entries[len(h.entries)-1] = nil
+	h.entries = h.entries[:len(h.entries)-1]
+	return last
+}
+
+func newEvictor[K, V any](limit int, policy EvictionPolicy, onEvict func(key K, value V)) *evictor[K, V] {
+	e := &evictor[K, V]{
+		limit:   limit,
+		policy:  policy,
+		onEvict: onEvict,
+		heap:    accessHeap[K, V]{lfu: policy == EvictLFU},
+	}
+	if e.tracking() {
+		e.entries = make(map[*Element[K, V]]*accessEntry[K, V])
+	}
+	return e
+}
+
+// tracking returns true if the policy needs access tracking.
+func (e *evictor[K, V]) tracking() bool {
+	return e.policy == EvictLRU || e.policy == EvictLFU
+}
+
+func (e *evictor[K, V]) add(elem *Element[K, V]) {
+	if !e.tracking() {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.tick++
+	entry := &accessEntry[K, V]{elem: elem, count: 1, tick: e.tick}
+	e.entries[elem] = entry
+	heap.Push(&e.heap, entry)
+}
+
+func (e *evictor[K, V]) remove(elem *Element[K, V]) {
+	if !e.tracking() {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if entry, ok := e.entries[elem]; ok {
+		delete(e.entries, elem)
+		heap.Remove(&e.heap, entry.index)
+	}
+}
+
+func (e *evictor[K, V]) touch(elem *Element[K, V]) {
+	if !e.tracking() {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if entry, ok := e.entries[elem]; ok {
+		e.tick++
+		entry.tick = e.tick
+		entry.count++
+		heap.Fix(&e.heap, entry.index)
+	}
+}
+
+func (e *evictor[K, V]) reset() {
+	if !e.tracking() {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	clear(e.entries)
+	clear(e.heap.entries)
+	e.heap.entries = e.heap.entries[:0]
+}
+
+// victim returns the element to evict next, keep is not chosen by EvictLRU and EvictLFU.
+func (e *evictor[K, V]) victim(list *skipListUnSafe[K, V], keep *Element[K, V]) *Element[K, V] {
+	switch e.policy {
+	case EvictSmallest:
+		return list.next[0]
+	case EvictLargest:
+		return list.back
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	entries := e.heap.entries
+	if len(entries) == 0 {
+		return nil
+	}
+	if entries[0].elem != keep {
+		return entries[0].elem
+	}
+	// the second smallest entry is a child of the root.
+	switch {
+	case len(entries) == 1:
+		return nil
+	case len(entries) == 2 || e.heap.Less(1, 2):
+		return entries[1].elem
+	default:
+		return entries[2].elem
+	}
+}
+
+// evict removes elements until the list fits its capacity and reports them to onEvict.
+// keep is a new element, which is not evicted by EvictLRU and EvictLFU.
+// Returns false if keep is evicted, which happens with EvictSmallest and EvictLargest.
+func (list *skipListUnSafe[K, V]) evict(keep *Element[K, V]) (kept bool) {
+	kept = true
+	e := list.evictor
+	if e == nil {
+		return
+	}
+	for list.length > e.limit {
+		victim := e.victim(list, keep)
+		if victim == nil {
+			return
+		}
+		if victim == keep {
+			kept = false
+		}
+		key, value := victim.key, victim.Value
+		list.discardElement(victim)
+		if e.onEvict != nil {
+			e.onEvict(key, value)
+		}
+	}
+	return
+}
+
+// touch records a use of elem for EvictLRU and EvictLFU.
+func (list *skipListUnSafe[K, V]) touch(elem *Element[K, V]) {
+	if list.evictor != nil && elem != nil {
+		list.evictor.touch(elem)
+	}
+}
@@ -0,0 +1,132 @@
+package skiplist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestCapacity(t *testing.T) {
+	a := assert.New(t)
+	var evicted []int
+	onEvict := WithOnEvict(func(key int, value string) {
+		evicted = append(evicted, key)
+	})
+
+	// keeps the 5 largest keys.
+	list := New[int, string](NumberComparator[int], WithCapacity(5, EvictSmallest), onEvict)
+	for _, i := range rand.Perm(20) {
+		list.Set(i, "v")
+		a.LessOrEqual(list.Len(), 5)
+	}
+	a.Equal([]int{15, 16, 17, 18, 19}, list.Keys())
+	a.Len(evicted, 15)
+	assertSanity(a, list)
+	// the new element is evicted right away.
+	a.Nil(list.Set(0, "v"))
+	a.Nil(list.Get(0))
+	a.Equal(0, evicted[len(evicted)-1])
+	a.Nil(list.SetWithTTL(0, "v", time.Second))
+	elem, loaded := list.GetOrSet(0, "v")
+	a.Nil(elem)
+	a.False(loaded)
+	a.Nil(list.Compute(0, func(string, bool) (string, ComputeAction) { return "v", ComputeUpdate }))
+	a.Equal(20, list.Set(20, "v").Key())
+	a.Equal([]int{16, 17, 18, 19, 20}, list.Keys())
+
+	pooled := New[int, string](NumberComparator[int], WithCapacity(1, EvictLargest), WithPool())
+	pooled.Set(1, "a")
+	a.Nil(pooled.Set(2, "b"))
+	a.Equal(0, pooled.Set(0, "c").Key())
+	a.Equal([]int{0}, pooled.Keys())
+
+	evicted = nil
+	list = New[int, string](NumberComparator[int], WithCapacity(3, EvictLargest), onEvict, WithMutex())
+	for i := 10; i > 0; i-- {
+		list.Set(i, "v")
+	}
+	a.Equal([]int{1, 2, 3}, list.Keys())
+	a.Equal([]int{10, 9, 8, 7, 6, 5, 4}, evicted)
+
+	sorted, err := FromSorted(NumberComparator[int], []int{1, 2, 3, 4}, []string{"a", "b", "c", "d"}, WithCapacity(2, EvictLargest))
+	a.NoError(err)
+	a.Equal([]int{1, 2}, sorted.Keys())
+	a.Panics(func() { New[int, string](NumberComparator[int], WithCapacity(-1, EvictLRU)) })
+}
+
+func TestCapacityLRU(t *testing.T) {
+	a := assert.New(t)
+	var evicted []int
+	list := New[int, int](NumberComparator[int], WithCapacity(3, EvictLRU), WithOnEvict(func(key, value int) {
+		evicted = append(evicted, key)
+	}), WithMutex())
+	list.Set(1, 1)
+	list.Set(2, 2)
+	list.Set(3, 3)
+	list.Get(1)
+	list.Set(4, 4)
+	a.Equal([]int{2}, evicted)
+	list.GetValue(3)
+	list.Set(1, 10)
+	list.Set(5, 5)
+	a.Equal([]int{2, 4}, evicted)
+	a.Equal([]int{1, 3, 5}, list.Keys())
+
+	list.Remove(3)
+	list.Set(6, 6)
+	a.Equal([]int{2, 4}, evicted)
+	list.Set(7, 7)
+	a.Equal([]int{2, 4, 1}, evicted)
+	a.Equal([]int{5, 6, 7}, list.Keys())
+	assertSanity(a, list)
+}
+
+func TestCapacityLFU(t *testing.T) {
+	a := assert.New(t)
+	var evicted []int
+	list := New[int, int](NumberComparator[int], WithCapacity(3, EvictLFU), WithOnEvict(func(key, value int) {
+		evicted = append(evicted, key)
+	}))
+	list.Set(1, 1)
+	list.Set(2, 2)
+	list.Set(3, 3)
+	for i := 0; i < 3; i++ {
+		list.Get(1)
+		list.Get(3)
+	}
+	list.Get(2)
+	list.Set(4, 4)
+	a.Equal([]int{2}, evicted)
+	// the new element isn't evicted, even though it's used the least.
+	list.Set(5, 5)
+	a.Equal([]int{2, 4}, evicted)
+	list.GetOrSet(5, 0)
+	list.GetOrSet(6, 6)
+	a.Equal([]int{2, 4, 5}, evicted)
+	a.Equal([]int{1, 3, 6}, list.Keys())
+
+	batch := NewBatch[int, int](nil).Set(7, 7).Set(8, 8).Remove(1)
+	a.NoError(list.Apply(batch))
+	a.Equal(3, list.Len())
+	a.Contains(list.Keys(), 3)
+	list.Init()
+	list.Set(9, 9)
+	a.Equal([]int{9}, list.Keys())
+
+	// a rolled back update isn't counted as a use.
+	list.Set(10, 10)
+	list.Set(11, 11)
+	evicted = nil
+	reject := NewBatch(func(op BatchOp[int, int], _ int, _ bool) error {
+		if op.Key == 12 {
+			return assert.AnError
+		}
+		return nil
+	})
+	reject.Set(9, 0).Set(9, 0).Set(12, 12)
+	a.ErrorIs(list.Apply(reject), assert.AnError)
+	list.Set(13, 13)
+	a.Equal([]int{9}, evicted)
+	a.Equal([]int{10, 11, 13}, list.Keys())
+}
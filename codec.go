@@ -0,0 +1,112 @@
+package skiplist
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// ErrInvalidEncoding is returned by a codec when data can't be decoded.
+var ErrInvalidEncoding = errors.New("skiplist: invalid encoding")
+
+// Codec encodes and decodes keys or values of a skip list.
+type Codec[T any] interface {
+	// Encode appends the encoded v to dst and returns the extended buffer.
+	Encode(dst []byte, v T) ([]byte, error)
+	// Decode decodes a value from data produced by Encode.
+	Decode(data []byte) (v T, err error)
+}
+
+// NumberCodec is a Codec for Numbers.
+// Integers are encoded as varints and floats as IEEE 754 bits.
+type NumberCodec[T Numbers] struct{}
+
+// Encode appends the encoded v to dst and returns the extended buffer.
+func (NumberCodec[T]) Encode(dst []byte, v T) ([]byte, error) {
+	switch {
+	case isFloat[T]():
+		return binary.LittleEndian.AppendUint64(dst, math.Float64bits(float64(v))), nil
+	case isSigned[T]():
+		return binary.AppendVarint(dst, int64(v)), nil
+	default:
+		return binary.AppendUvarint(dst, uint64(v)), nil
+	}
+}
+
+// Decode decodes a value from data produced by Encode.
+func (NumberCodec[T]) Decode(data []byte) (v T, err error) {
+	switch {
+	case isFloat[T]():
+		if len(data) != 8 {
+			return v, ErrInvalidEncoding
+		}
+		return T(math.Float64frombits(binary.LittleEndian.Uint64(data))), nil
+	case isSigned[T]():
+		x, n := binary.Varint(data)
+		if n <= 0 || n != len(data) {
+			return v, ErrInvalidEncoding
+		}
+		return T(x), nil
+	default:
+		x, n := binary.Uvarint(data)
+		if n <= 0 || n != len(data) {
+			return v, ErrInvalidEncoding
+		}
+		return T(x), nil
+	}
+}
+
+// BytesCodec is a Codec for Bytes, which stores the raw bytes.
+type BytesCodec[T Bytes] struct{}
+
+// Encode appends the encoded v to dst and returns the extended buffer.
+func (BytesCodec[T]) Encode(dst []byte, v T) ([]byte, error) {
+	return append(dst, v...), nil
+}
+
+// Decode decodes a value from data produced by Encode.
+func (BytesCodec[T]) Decode(data []byte) (v T, err error) {
+	return T(append([]byte{}, data...)), nil
+}
+
+func isFloat[T Numbers]() bool {
+	var one T = 1
+	return one/2 != 0
+}
+
+func isSigned[T Numbers]() bool {
+	var zero T
+	return zero-1 < 0
+}
+
+// appendRecord appends v encoded by codec with its uvarint length prefix to dst.
+func appendRecord[T any](dst []byte, codec Codec[T], v T) ([]byte, error) {
+	start := len(dst)
+	dst, err := codec.Encode(dst, v)
+	if err != nil {
+		return dst, err
+	}
+	// move the encoded bytes behind their length.
+	data := append([]byte{}, dst[start:]...)
+	dst = binary.AppendUvarint(dst[:start], uint64(len(data)))
+	return append(dst, data...), nil
+}
+
+// decodeRecord decodes a record written by appendRecord from data and returns the rest of data.
+func decodeRecord[T any](data []byte, codec Codec[T]) (v T, rest []byte, err error) {
+	record, rest, err := splitRecord(data)
+	if err != nil {
+		return
+	}
+	v, err = codec.Decode(record)
+	return
+}
+
+// splitRecord splits a length-prefixed record from data without decoding it.
+func splitRecord(data []byte) (record, rest []byte, err error) {
+	l, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < l {
+		return nil, nil, ErrInvalidEncoding
+	}
+	return data[n : n+int(l)], data[n+int(l):], nil
+}
@@ -30,6 +30,8 @@ func NumberComparator[K Numbers](lk, rk K) int {
 	return 0
 }
 
+// BytesComparator compares keys in lexicographic order.
+// The first 8 bytes are compared at once as a fast path, the rest byte by byte.
 func BytesComparator[K Bytes](lk, rk K) int {
 	lhs, rhs := bytesScore(lk), bytesScore(rk)
 	if lhs > rhs {
@@ -38,7 +40,15 @@ func BytesComparator[K Bytes](lk, rk K) int {
 	if lhs < rhs {
 		return -1
 	}
-	return 0
+	for i, l := 8, min(len(lk), len(rk)); i < l; i++ {
+		if lk[i] > rk[i] {
+			return 1
+		}
+		if lk[i] < rk[i] {
+			return -1
+		}
+	}
+	return NumberComparator(len(lk), len(rk))
 }
 
 func bytesScore[K Bytes](data K) (score uint64) {
@@ -0,0 +1,99 @@
+package skiplist
+
+// ComputeAction tells Compute what to do with the value returned by its callback.
+type ComputeAction int
+
+const (
+	// ComputeUpdate sets the returned value for the key, inserting the key if it doesn't exist.
+	ComputeUpdate ComputeAction = iota
+	// ComputeCancel leaves the list unchanged.
+	ComputeCancel
+	// ComputeDelete removes the key.
+	ComputeDelete
+)
+
+// GetOrSet returns the existing element with the key.
+// Otherwise, it sets value for the key and returns the new element, or nil if it's evicted right away, see WithCapacity.
+// loaded is true if the element already existed.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) GetOrSet(key K, value V) (elem *Element[K, V], loaded bool) {
+	prevs := list.getPrevElementNodes(key)
+	if elem = list.dropExpired(prevs, key); elem != nil && list.comparable(elem.key, key) == 0 {
+		list.touch(elem)
+		return elem, true
+	}
+	elem = list.insertElement(prevs, key, value)
+	if !list.evict(elem) {
+		return nil, false
+	}
+	return elem, false
+}
+
+// Compute calls f with the current value of the key, exists is false if the key doesn't exist.
+// Depending on the returned action, the key is updated with the returned value, removed, or left unchanged.
+// If the list allows duplicates, only the first element with the key is used.
+// Returns the element holding the key after Compute, or nil if there is none.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) Compute(key K, f func(old V, exists bool) (value V, action ComputeAction)) (elem *Element[K, V]) {
+	prevs := list.getPrevElementNodes(key)
+	var old V
+	exists := false
+	if elem = list.dropExpired(prevs, key); elem != nil && list.comparable(elem.key, key) == 0 {
+		old, exists = elem.Value, true
+	} else {
+		elem = nil
+	}
+	value, action := f(old, exists)
+	switch action {
+	case ComputeUpdate:
+		if exists {
+			list.preserve(elem)
+			elem.Value = value
+			list.touch(elem)
+			return elem
+		}
+		elem = list.insertElement(prevs, key, value)
+		if !list.evict(elem) {
+			return nil
+		}
+		return elem
+	case ComputeDelete:
+		if exists {
+			list.unlinkElement(prevs, elem)
+		} else {
+			list.tombstone(key)
+		}
+		return nil
+	}
+	return elem
+}
+
+// CompareAndSwap sets new for the key if its current value is equal to old.
+// Values are compared with ==, so old must be of a comparable type, otherwise it panics.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	elem := list.Get(key)
+	if elem == nil || any(elem.Value) != any(old) {
+		return false
+	}
+	list.preserve(elem)
+	elem.Value = new
+	return true
+}
+
+// CompareAndDelete removes the key if its current value is equal to old.
+// Values are compared with ==, so old must be of a comparable type, otherwise it panics.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	prevs := list.getPrevElementNodes(key)
+	elem := list.dropExpired(prevs, key)
+	if elem == nil || list.comparable(elem.key, key) != 0 || any(elem.Value) != any(old) {
+		return false
+	}
+	list.unlinkElement(prevs, elem)
+	return true
+}
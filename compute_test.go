@@ -0,0 +1,98 @@
+package skiplist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestGetOrSet(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, string](NumberComparator[int])
+	elem, loaded := list.GetOrSet(1, "a")
+	a.False(loaded)
+	a.Equal("a", elem.Value)
+	elem, loaded = list.GetOrSet(1, "b")
+	a.True(loaded)
+	a.Equal("a", elem.Value)
+	a.Equal(1, list.Len())
+	assertSanity(a, list)
+}
+
+func TestCompute(t *testing.T) {
+	a := assert.New(t)
+	list := New[string, int](BytesComparator[string])
+	increment := func(old int, exists bool) (int, ComputeAction) {
+		return old + 1, ComputeUpdate
+	}
+	a.Equal(1, list.Compute("a", increment).Value)
+	a.Equal(2, list.Compute("a", increment).Value)
+	elem := list.Compute("b", func(old int, exists bool) (int, ComputeAction) {
+		a.False(exists)
+		return 0, ComputeCancel
+	})
+	a.True(elem == nil)
+	a.True(list.Get("b") == nil)
+	elem = list.Compute("a", func(old int, exists bool) (int, ComputeAction) {
+		a.True(exists)
+		a.Equal(2, old)
+		return 0, ComputeCancel
+	})
+	a.Equal(2, elem.Value)
+	a.True(list.Compute("a", func(old int, exists bool) (int, ComputeAction) {
+		return 0, ComputeDelete
+	}) == nil)
+	a.Equal(0, list.Len())
+	assertSanity(a, list)
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, any](NumberComparator[int])
+	a.False(list.CompareAndSwap(1, nil, "a"))
+	list.Set(1, "a")
+	a.False(list.CompareAndSwap(1, "b", "c"))
+	a.True(list.CompareAndSwap(1, "a", "c"))
+	a.Equal("c", list.MustGetValue(1))
+	a.False(list.CompareAndDelete(1, "a"))
+	a.True(list.CompareAndDelete(1, "c"))
+	a.Equal(0, list.Len())
+	list.Set(2, []int{1})
+	a.Panics(func() { list.CompareAndSwap(2, []int{1}, nil) })
+}
+
+func TestSafeSkipList_Compute(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, int](NumberComparator[int], WithMutex())
+	wg := sync.WaitGroup{}
+	inserted := make(chan bool, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list.Compute(0, func(old int, exists bool) (int, ComputeAction) {
+				return old + 1, ComputeUpdate
+			})
+			_, loaded := list.GetOrSet(1, 0)
+			inserted <- !loaded
+			for {
+				v, _ := list.GetValue(2)
+				if list.CompareAndSwap(2, v, v+1) {
+					break
+				}
+			}
+		}()
+	}
+	list.Set(2, 0)
+	wg.Wait()
+	close(inserted)
+	count := 0
+	for ok := range inserted {
+		if ok {
+			count++
+		}
+	}
+	a.Equal(1, count)
+	a.Equal(100, list.MustGetValue(0))
+	a.Equal(3, list.Len())
+}
@@ -0,0 +1,517 @@
+package skiplist
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncPolicy decides when Durable flushes its log to stable storage.
+type SyncPolicy int
+
+const (
+	// SyncAlways syncs the log after every write, a returned write is never lost.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval syncs the log periodically, writes of the last interval can be lost on a crash.
+	SyncInterval
+	// SyncNever leaves syncing to the OS, call Sync to sync manually.
+	SyncNever
+)
+
+// DurableOptions holds Durable's options
+type DurableOptions struct {
+	syncPolicy   SyncPolicy
+	syncInterval time.Duration
+}
+
+// DurableOption is a function used to set DurableOptions
+type DurableOption func(option *DurableOptions)
+
+// WithSyncPolicy sets when Durable syncs its log
+func WithSyncPolicy(policy SyncPolicy) DurableOption {
+	return func(option *DurableOptions) {
+		option.syncPolicy = policy
+	}
+}
+
+// WithSyncInterval sets SyncInterval policy with interval
+func WithSyncInterval(interval time.Duration) DurableOption {
+	return func(option *DurableOptions) {
+		option.syncPolicy = SyncInterval
+		option.syncInterval = interval
+	}
+}
+
+// ErrClosed is returned when a closed Durable is used.
+var ErrClosed = errors.New("skiplist: durable list is closed")
+
+// ErrLogFailed is returned when Durable couldn't undo a failed write to its log.
+// The log may hold a record which isn't applied to the list, so Durable must be opened again.
+var ErrLogFailed = errors.New("skiplist: durable log failed")
+
+const (
+	walSuffix      = ".wal"
+	snapshotSuffix = ".snapshot"
+
+	walOpSet    byte = 1
+	walOpRemove byte = 2
+
+	// walHeaderSize is the size of the record header, payload length and crc32 of payload.
+	walHeaderSize = 8
+)
+
+// Durable makes writes to a SkipList durable with a write-ahead log in a directory.
+// Every Set and Remove is appended to the log before it is applied to the list,
+// and the list is rebuilt from the latest snapshot and the log when opened again.
+//
+// Log record format:
+//
+//	length  uint32  big-endian length of payload
+//	crc32   uint32  big-endian IEEE checksum of payload
+//	payload op byte, uvarint key length, key, and for Set, uvarint value length, value
+//
+// Files in the directory are named by sequence numbers, N.snapshot holds all writes of the logs up to N.wal.
+type Durable[K, V any] struct {
+	list       SkipList[K, V]
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+	option     *DurableOptions
+	dir        string
+
+	lock   sync.Mutex
+	log    logFile
+	size   int64 // size of the log up to the last complete record.
+	seq    uint64
+	buf    []byte
+	dirty  bool
+	closed bool
+	failed error // the error which left the log inconsistent with the list.
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// logFile is the part of *os.File used to append to the log.
+type logFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+// OpenDurable opens the log in dir for list, creating dir if needed.
+// list must be empty and created WithCodec, its codecs are used for the log and snapshots.
+// The latest snapshot and the logs after it are replayed into list,
+// a torn record at the end of the last log is truncated.
+func OpenDurable[K, V any](dir string, list SkipList[K, V], options ...DurableOption) (durable *Durable[K, V], err error) {
+	keyCodec, valueCodec := codecsOfList(list)
+	if keyCodec == nil || valueCodec == nil {
+		return nil, ErrNoCodec
+	}
+	if n := list.Len(); n != 0 {
+		return nil, fmt.Errorf("skiplist: list of OpenDurable must be empty (length is %v)", n)
+	}
+	option := &DurableOptions{
+		syncPolicy:   SyncAlways,
+		syncInterval: time.Second,
+	}
+	for _, o := range options {
+		o(option)
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+	durable = &Durable[K, V]{
+		list:       list,
+		keyCodec:   keyCodec,
+		valueCodec: valueCodec,
+		option:     option,
+		dir:        dir,
+	}
+	if err = durable.load(); err != nil {
+		return nil, err
+	}
+	if option.syncPolicy == SyncInterval {
+		durable.stop = make(chan struct{})
+		durable.done = make(chan struct{})
+		go durable.syncLoop()
+	}
+	return durable, nil
+}
+
+// List returns the underlying list.
+// It must only be read, writes that bypass Durable are not logged.
+func (d *Durable[K, V]) List() SkipList[K, V] {
+	return d.list
+}
+
+// Set logs and sets value for the key.
+// Returns the element holding the key and value.
+func (d *Durable[K, V]) Set(key K, value V) (elem *Element[K, V], err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if err = d.append(walOpSet, key, value); err != nil {
+		return
+	}
+	return d.list.Set(key, value), nil
+}
+
+// Remove logs and removes the key.
+// Returns removed element pointer if found, nil if it's not found.
+func (d *Durable[K, V]) Remove(key K) (elem *Element[K, V], err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	var value V
+	if err = d.append(walOpRemove, key, value); err != nil {
+		return
+	}
+	return d.list.Remove(key), nil
+}
+
+// Sync flushes the log to stable storage.
+func (d *Durable[K, V]) Sync() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.closed {
+		return ErrClosed
+	}
+	return d.sync()
+}
+
+// Compact writes a snapshot of the list and starts a new empty log, then removes the old log and snapshot.
+func (d *Durable[K, V]) Compact() (err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.closed {
+		return ErrClosed
+	}
+	if err = d.sync(); err != nil {
+		return
+	}
+	seq := d.seq
+	if err = d.openLog(seq + 1); err != nil {
+		return
+	}
+	if err = d.writeSnapshot(seq); err != nil {
+		return
+	}
+	return d.removeBefore(seq)
+}
+
+// Close syncs and closes the log.
+func (d *Durable[K, V]) Close() (err error) {
+	d.lock.Lock()
+	if d.closed {
+		d.lock.Unlock()
+		return ErrClosed
+	}
+	d.closed = true
+	d.lock.Unlock()
+	if d.stop != nil {
+		close(d.stop)
+		<-d.done
+	}
+	if err = d.log.Sync(); err != nil {
+		_ = d.log.Close()
+		return
+	}
+	return d.log.Close()
+}
+
+// append writes a record to the log, the lock must be held.
+// If the record can't be written completely, the log is truncated back to the last complete record.
+func (d *Durable[K, V]) append(op byte, key K, value V) (err error) {
+	if d.closed {
+		return ErrClosed
+	}
+	if d.failed != nil {
+		return fmt.Errorf("%w: %w", ErrLogFailed, d.failed)
+	}
+	buf := append(d.buf[:0], make([]byte, walHeaderSize)...)
+	buf = append(buf, op)
+	if buf, err = appendRecord(buf, d.keyCodec, key); err != nil {
+		return
+	}
+	if op == walOpSet {
+		if buf, err = appendRecord(buf, d.valueCodec, value); err != nil {
+			return
+		}
+	}
+	payload := buf[walHeaderSize:]
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	d.buf = buf
+	if _, err = d.log.Write(buf); err != nil {
+		return d.discard(err)
+	}
+	d.dirty = true
+	if d.option.syncPolicy == SyncAlways {
+		if err = d.sync(); err != nil {
+			return d.discard(err)
+		}
+	}
+	d.size += int64(len(buf))
+	return nil
+}
+
+// discard truncates the log back to the last complete record after err, so the failed record is never replayed.
+// If the log can't be truncated, Durable fails.
+func (d *Durable[K, V]) discard(err error) error {
+	if truncErr := d.log.Truncate(d.size); truncErr != nil {
+		d.failed = errors.Join(err, truncErr)
+		return fmt.Errorf("%w: %w", ErrLogFailed, d.failed)
+	}
+	return err
+}
+
+func (d *Durable[K, V]) sync() error {
+	if !d.dirty {
+		return nil
+	}
+	d.dirty = false
+	return d.log.Sync()
+}
+
+func (d *Durable[K, V]) syncLoop() {
+	defer close(d.done)
+	ticker := time.NewTicker(d.option.syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.lock.Lock()
+			_ = d.sync()
+			d.lock.Unlock()
+		}
+	}
+}
+
+// load loads the latest snapshot and replays the logs after it.
+func (d *Durable[K, V]) load() error {
+	snapshots, logs, err := d.files()
+	if err != nil {
+		return err
+	}
+	var base uint64
+	if len(snapshots) > 0 {
+		base = snapshots[len(snapshots)-1]
+		if err = d.readSnapshot(base); err != nil {
+			return err
+		}
+	}
+	d.seq = base + 1
+	for i, seq := range logs {
+		if seq <= base {
+			continue
+		}
+		if err = d.replay(seq, i == len(logs)-1); err != nil {
+			return err
+		}
+		d.seq = seq
+	}
+	return d.openLog(d.seq)
+}
+
+// files returns sequence numbers of snapshots and logs in dir, in ascending order.
+func (d *Durable[K, V]) files() (snapshots, logs []uint64, err error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, ext), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch ext {
+		case snapshotSuffix:
+			snapshots = append(snapshots, seq)
+		case walSuffix:
+			logs = append(logs, seq)
+		}
+	}
+	slices.Sort(snapshots)
+	slices.Sort(logs)
+	return
+}
+
+func (d *Durable[K, V]) path(seq uint64, suffix string) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%020d%s", seq, suffix))
+}
+
+// replay applies all records of a log to the list.
+// If last is true, a torn record at the end is truncated, otherwise it is an error.
+func (d *Durable[K, V]) replay(seq uint64, last bool) error {
+	path := d.path(seq, walSuffix)
+	file, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	var offset int64
+	var header [walHeaderSize]byte
+	var payload []byte
+	for {
+		if _, err = io.ReadFull(r, header[:]); err != nil {
+			break
+		}
+		length := binary.BigEndian.Uint32(header[0:4])
+		if length > maxSnapshotRecord {
+			err = ErrInvalidSnapshot
+			break
+		}
+		payload = slices.Grow(payload[:0], int(length))[:length]
+		if _, err = io.ReadFull(r, payload); err != nil {
+			break
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			err = ErrChecksumMismatch
+			break
+		}
+		if err = d.apply(payload); err != nil {
+			break
+		}
+		offset += walHeaderSize + int64(length)
+	}
+	if err == io.EOF {
+		return nil
+	}
+	if !last {
+		return fmt.Errorf("skiplist: corrupted log %v at %v: %w", path, offset, err)
+	}
+	// torn write at the end of the last log.
+	if err = file.Truncate(offset); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// apply decodes a log record and applies it to the list.
+func (d *Durable[K, V]) apply(payload []byte) error {
+	if len(payload) == 0 {
+		return ErrInvalidEncoding
+	}
+	op, data := payload[0], payload[1:]
+	key, data, err := decodeRecord(data, d.keyCodec)
+	if err != nil {
+		return err
+	}
+	switch op {
+	case walOpSet:
+		value, _, err := decodeRecord(data, d.valueCodec)
+		if err != nil {
+			return err
+		}
+		d.list.Set(key, value)
+	case walOpRemove:
+		d.list.Remove(key)
+	default:
+		return ErrInvalidEncoding
+	}
+	return nil
+}
+
+// openLog closes the current log and opens the log seq for appending.
+func (d *Durable[K, V]) openLog(seq uint64) error {
+	file, err := os.OpenFile(d.path(seq, walSuffix), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if err = syncDir(d.dir); err != nil {
+		_ = file.Close()
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	if d.log != nil {
+		_ = d.log.Close()
+	}
+	d.log = file
+	d.size = info.Size()
+	d.seq = seq
+	d.dirty = false
+	return nil
+}
+
+// writeSnapshot writes the list as the snapshot seq, through a temporary file.
+func (d *Durable[K, V]) writeSnapshot(seq uint64) error {
+	path := d.path(seq, snapshotSuffix)
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = d.list.WriteTo(file)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+	if err != nil {
+		_ = os.Remove(path + ".tmp")
+		return err
+	}
+	return syncDir(d.dir)
+}
+
+func (d *Durable[K, V]) readSnapshot(seq uint64) error {
+	file, err := os.Open(d.path(seq, snapshotSuffix))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = d.list.ReadFrom(file)
+	return err
+}
+
+// removeBefore removes logs up to seq and snapshots before seq.
+func (d *Durable[K, V]) removeBefore(seq uint64) error {
+	snapshots, logs, err := d.files()
+	if err != nil {
+		return err
+	}
+	for _, s := range snapshots {
+		if s < seq {
+			if err = os.Remove(d.path(s, snapshotSuffix)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, s := range logs {
+		if s <= seq {
+			if err = os.Remove(d.path(s, walSuffix)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
@@ -0,0 +1,182 @@
+package skiplist
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func newDurableList() SkipList[int, string] {
+	return New[int, string](NumberComparator[int], WithCodec[int, string](NumberCodec[int]{}, BytesCodec[string]{}))
+}
+
+func TestDurable(t *testing.T) {
+	a := assert.New(t)
+	dir := t.TempDir()
+
+	_, err := OpenDurable(dir, New[int, string](NumberComparator[int]))
+	a.ErrorIs(err, ErrNoCodec)
+
+	durable, err := OpenDurable(dir, newDurableList())
+	a.NoError(err)
+	for i := 0; i < 100; i++ {
+		elem, err := durable.Set(i, string(rune('a'+i%26)))
+		a.NoError(err)
+		a.Equal(i, elem.Key())
+	}
+	for i := 0; i < 100; i += 3 {
+		elem, err := durable.Remove(i)
+		a.NoError(err)
+		a.NotNil(elem)
+	}
+	elem, err := durable.Remove(1000)
+	a.NoError(err)
+	a.Nil(elem)
+	a.NoError(durable.Close())
+	a.ErrorIs(durable.Close(), ErrClosed)
+	_, err = durable.Set(0, "a")
+	a.ErrorIs(err, ErrClosed)
+
+	reopened, err := OpenDurable(dir, newDurableList())
+	a.NoError(err)
+	a.Equal(durable.List().Keys(), reopened.List().Keys())
+	a.Equal(durable.List().Values(), reopened.List().Values())
+	assertSanity(a, reopened.List())
+
+	// compaction replaces the logs with a snapshot.
+	a.NoError(reopened.Compact())
+	_, err = reopened.Set(1000, "z")
+	a.NoError(err)
+	_, err = reopened.Remove(1)
+	a.NoError(err)
+	a.NoError(reopened.Compact())
+	_, err = reopened.Set(2000, "y")
+	a.NoError(err)
+	a.NoError(reopened.Close())
+
+	snapshots, logs, err := reopened.files()
+	a.NoError(err)
+	a.Len(snapshots, 1)
+	a.Len(logs, 1)
+
+	compacted, err := OpenDurable(dir, newDurableList(), WithSyncPolicy(SyncNever))
+	a.NoError(err)
+	a.Equal(reopened.List().Keys(), compacted.List().Keys())
+	a.Equal(reopened.List().Values(), compacted.List().Values())
+	a.NoError(compacted.Sync())
+	a.NoError(compacted.Close())
+}
+
+func TestDurableTornTail(t *testing.T) {
+	a := assert.New(t)
+	dir := t.TempDir()
+
+	durable, err := OpenDurable(dir, newDurableList(), WithSyncInterval(time.Millisecond))
+	a.NoError(err)
+	for i := 0; i < 10; i++ {
+		_, err = durable.Set(i, "v")
+		a.NoError(err)
+	}
+	a.NoError(durable.Close())
+
+	path := durable.path(durable.seq, walSuffix)
+	info, err := os.Stat(path)
+	a.NoError(err)
+	size := info.Size()
+
+	// a torn record, half of the header.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	a.NoError(err)
+	_, err = file.Write([]byte{0, 0, 0})
+	a.NoError(err)
+	a.NoError(file.Close())
+
+	reopened, err := OpenDurable(dir, newDurableList())
+	a.NoError(err)
+	a.Equal(10, reopened.List().Len())
+	info, err = os.Stat(path)
+	a.NoError(err)
+	a.Equal(size, info.Size())
+
+	// a corrupted last record is dropped.
+	_, err = reopened.Set(10, "v")
+	a.NoError(err)
+	a.NoError(reopened.Close())
+	data, err := os.ReadFile(path)
+	a.NoError(err)
+	data[len(data)-1] ^= 0xff
+	a.NoError(os.WriteFile(path, data, 0o644))
+
+	reopened, err = OpenDurable(dir, newDurableList())
+	a.NoError(err)
+	a.Equal(10, reopened.List().Len())
+	a.NoError(reopened.Close())
+
+	// corruption of a log that is not the last one is an error.
+	a.NoError(os.Rename(path, durable.path(durable.seq+1, walSuffix)))
+	a.NoError(os.WriteFile(path, []byte{0, 0, 0, 1, 0, 0, 0, 0, 1}, 0o644))
+	_, err = OpenDurable(dir, newDurableList())
+	a.ErrorIs(err, ErrChecksumMismatch)
+}
+
+// failingLog writes only a part of the next record, and fails to truncate if truncate is set.
+type failingLog struct {
+	logFile
+	partial  bool
+	truncate error
+}
+
+func (f *failingLog) Write(p []byte) (int, error) {
+	if f.partial {
+		f.partial = false
+		n, _ := f.logFile.Write(p[:len(p)/2])
+		return n, errors.New("disk full")
+	}
+	return f.logFile.Write(p)
+}
+
+func (f *failingLog) Truncate(size int64) error {
+	if f.truncate != nil {
+		return f.truncate
+	}
+	return f.logFile.Truncate(size)
+}
+
+func TestDurableFailedWrite(t *testing.T) {
+	a := assert.New(t)
+	dir := t.TempDir()
+
+	list := newDurableList()
+	list.Set(1, "a")
+	_, err := OpenDurable(dir, list)
+	a.Error(err)
+
+	durable, err := OpenDurable(dir, newDurableList())
+	a.NoError(err)
+	_, err = durable.Set(1, "a")
+	a.NoError(err)
+	log := &failingLog{logFile: durable.log, partial: true}
+	durable.log = log
+	_, err = durable.Set(2, "b")
+	a.Error(err)
+	a.NotErrorIs(err, ErrLogFailed)
+	// the partial record is truncated, so later records are replayed.
+	_, err = durable.Set(3, "c")
+	a.NoError(err)
+	a.NoError(durable.Close())
+
+	reopened, err := OpenDurable(dir, newDurableList())
+	a.NoError(err)
+	a.Equal([]int{1, 3}, reopened.List().Keys())
+
+	log = &failingLog{logFile: reopened.log, partial: true, truncate: errors.New("io error")}
+	reopened.log = log
+	_, err = reopened.Set(4, "d")
+	a.ErrorIs(err, ErrLogFailed)
+	_, err = reopened.Remove(1)
+	a.ErrorIs(err, ErrLogFailed)
+	a.Equal([]int{1, 3}, reopened.List().Keys())
+	a.NoError(reopened.Close())
+}
@@ -9,27 +9,57 @@ type Element[K, V any] struct {
 	Value V
 	key   K
 	prev  *Element[K, V] // Points to previous adjacent elem.
-	list  SkipList[K, V] // The list contains this elem.
-	skip  int
+	list  ReadView[K, V] // The list, the snapshot or the lock-free list contains this elem.
+
+	expireAt int64  // Unix time in nanoseconds when elem expires, 0 if it never expires.
+	seq      uint64 // Insertion order of elem in its list, it orders elements with equal keys.
+	version  uint64 // Snapshot version of the list when elem was inserted or last changed.
 }
 
 // elementHeader is the header of an element or a skip list.
 // It must be the first anonymous field in a type to make Element() work correctly.
 type elementHeader[K, V any] struct {
 	next []*Element[K, V] // Next element at all next.
+	span []int            // Number of level 0 steps to reach next element at all next.
+}
+
+// elementCopier is a view whose elements are copies, with no links to adjacent elements.
+type elementCopier[K, V any] interface {
+	// step returns the element after or before elem.
+	step(elem *Element[K, V], forward bool) *Element[K, V]
+}
+
+// copyStep returns the element after or before a copied element.
+// Returns nil if elem is not a copy.
+func (elem *Element[K, V]) copyStep(forward bool) *Element[K, V] {
+	if copier, ok := elem.list.(elementCopier[K, V]); ok {
+		return copier.step(elem, forward)
+	}
+	return nil
 }
 
 // Next returns next adjacent elem.
+// Expired elements are skipped.
 func (elem *Element[K, V]) Next() *Element[K, V] {
 	if len(elem.next) == 0 {
-		return nil
+		return elem.copyStep(true)
+	}
+	if next := elem.next[0]; next == nil || next.expireAt == 0 {
+		return next
 	}
-	return elem.next[0]
+	return skipExpired(elem.next[0], true)
 }
 
 // Prev returns previous adjacent elem.
+// Expired elements are skipped.
 func (elem *Element[K, V]) Prev() *Element[K, V] {
-	return elem.prev
+	if len(elem.next) == 0 {
+		return elem.copyStep(false)
+	}
+	if prev := elem.prev; prev == nil || prev.expireAt == 0 {
+		return prev
+	}
+	return skipExpired(elem.prev, false)
 }
 
 // NextLevel returns next element at specific level.
@@ -52,6 +82,11 @@ func (elem *Element[K, V]) Level() int {
 	return len(elem.next)
 }
 
+// Index returns the position of the elem in its list.
+// Like Next and Prev, it doesn't take the lock of a list created WithMutex, so it isn't goroutine-safe:
+// call it inside View, where the read lock is held, or call Index of the list instead.
+//
+// The complexity is O(log(N)).
 func (elem *Element[K, V]) Index() int {
 	return elem.list.Index(elem)
 }
@@ -0,0 +1,252 @@
+package skiplist
+
+import (
+	"container/heap"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// expiry is the state of a list with elements set by SetWithTTL.
+type expiry[K, V any] struct {
+	clock     func() time.Time
+	onExpire  func(key K, value V)
+	entries   map[*Element[K, V]]*deadline[K, V]
+	deadlines deadlineHeap[K, V]
+}
+
+// deadline is the pending expiry of an element, each element has at most one.
+// It's stale if the element was set again without ttl later, then it's dropped when it's due.
+type deadline[K, V any] struct {
+	elem  *Element[K, V]
+	at    int64
+	index int
+}
+
+// deadlineHeap orders deadlines by time.
+type deadlineHeap[K, V any] []*deadline[K, V]
+
+func (h deadlineHeap[K, V]) Len() int { return len(h) }
+
+func (h deadlineHeap[K, V]) Less(i, j int) bool { return h[i].at < h[j].at }
+
+func (h deadlineHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *deadlineHeap[K, V]) Push(x any) {
+	d := x.(*deadline[K, V])
+	d.index = len(*h)
+	*h = append(*h, d)
+}
+
+func (h *deadlineHeap[K, V]) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return last
+}
+
+func newExpiry[K, V any](clock func() time.Time, onExpire func(key K, value V)) *expiry[K, V] {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &expiry[K, V]{
+		clock:    clock,
+		onExpire: onExpire,
+		entries:  make(map[*Element[K, V]]*deadline[K, V]),
+	}
+}
+
+func (e *expiry[K, V]) now() int64 {
+	return e.clock().UnixNano()
+}
+
+// schedule sets the deadline of elem to its expireAt.
+func (e *expiry[K, V]) schedule(elem *Element[K, V]) {
+	if d, ok := e.entries[elem]; ok {
+		d.at = elem.expireAt
+		heap.Fix(&e.deadlines, d.index)
+		return
+	}
+	d := &deadline[K, V]{elem: elem, at: elem.expireAt}
+	e.entries[elem] = d
+	heap.Push(&e.deadlines, d)
+}
+
+// cancel drops the deadline of a removed elem.
+func (e *expiry[K, V]) cancel(elem *Element[K, V]) {
+	if d, ok := e.entries[elem]; ok {
+		delete(e.entries, elem)
+		heap.Remove(&e.deadlines, d.index)
+	}
+}
+
+func (e *expiry[K, V]) reset() {
+	clear(e.entries)
+	clear(e.deadlines)
+	e.deadlines = e.deadlines[:0]
+}
+
+// SetWithTTL sets value for the key like Set, and the element expires after ttl.
+// If ttl is not greater than 0, the element never expires.
+//
+// Expired elements are invisible to Get, Find, Front, Back, Next, Prev and iteration,
+// but Len, Index, GetByRank and CountRange count them until they are reclaimed.
+// They are reclaimed when Get or a write reaches them, and by RemoveExpired.
+// See WithExpiry to set the clock and a callback for expired elements, and StartSweeper to reclaim them periodically.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) SetWithTTL(key K, value V, ttl time.Duration) (element *Element[K, V]) {
+	element = list.Set(key, value)
+	if ttl <= 0 || element == nil {
+		return
+	}
+	if list.expiry == nil {
+		list.expiry = newExpiry[K, V](nil, nil)
+	}
+	element.expireAt = list.expiry.now() + int64(ttl)
+	list.expiry.schedule(element)
+	return
+}
+
+// RemoveExpired reclaims all expired elements.
+// Returns the number of reclaimed elements.
+//
+// The complexity is O(M*log(N)), M is the number of expired elements.
+func (list *skipListUnSafe[K, V]) RemoveExpired() (removed int) {
+	if list.expiry == nil {
+		return
+	}
+	e := list.expiry
+	now := e.now()
+	for len(e.deadlines) > 0 && e.deadlines[0].at <= now {
+		d := heap.Pop(&e.deadlines).(*deadline[K, V])
+		delete(e.entries, d.elem)
+		if d.elem.expireAt == 0 {
+			// set again without ttl.
+			continue
+		}
+		if prevs := list.prevsOf(d.elem); prevs != nil {
+			list.expireElement(prevs, d.elem)
+			removed++
+		}
+	}
+	return
+}
+
+// StartSweeper calls RemoveExpired of list every interval in a new goroutine until stop is called.
+// list must be safe for concurrent use, so StartSweeper panics if it's created without WithMutex or WithLockFree.
+func StartSweeper[K, V any](list SkipList[K, V], interval time.Duration) (stop func()) {
+	if _, ok := list.(*skipListUnSafe[K, V]); ok {
+		panic(fmt.Errorf("skiplist: StartSweeper needs a list created WithMutex or WithLockFree"))
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				list.RemoveExpired()
+			}
+		}
+	}()
+	return func() {
+		select {
+		case <-done:
+		default:
+			close(done)
+		}
+		<-stopped
+	}
+}
+
+// reclaim removes expired elements with the key.
+// Returns the number of removed elements.
+func (list *skipListUnSafe[K, V]) reclaim(key K) (removed int) {
+	prevs := list.getPrevElementNodes(key)
+	for elem := prevs[0].next[0]; elem != nil && list.comparable(elem.key, key) == 0; {
+		next := elem.next[0]
+		if elem.expired() {
+			list.expireElement(prevs, elem)
+			removed++
+		} else {
+			for i := range elem.next {
+				prevs[i] = elem.elementHeader
+			}
+		}
+		elem = next
+	}
+	return
+}
+
+// dropExpired reclaims expired elements with the key right after prevs.
+// Returns the element after prevs.
+func (list *skipListUnSafe[K, V]) dropExpired(prevs []*elementHeader[K, V], key K) (elem *Element[K, V]) {
+	for elem = prevs[0].next[0]; elem != nil && elem.expired() && list.comparable(elem.key, key) == 0; elem = prevs[0].next[0] {
+		list.expireElement(prevs, elem)
+	}
+	return
+}
+
+// expireElement unlinks an expired element and reports it to onExpire.
+func (list *skipListUnSafe[K, V]) expireElement(prevs []*elementHeader[K, V], elem *Element[K, V]) {
+	key, value := elem.key, elem.Value
+	list.unlinkElement(prevs, elem)
+	if list.expiry.onExpire != nil {
+		list.expiry.onExpire(key, value)
+	}
+}
+
+// expired returns true if the elem has expired.
+func (elem *Element[K, V]) expired() bool {
+	if elem.expireAt == 0 {
+		return false
+	}
+	list, ok := elem.list.(*skipListUnSafe[K, V])
+	return ok && list.expiry != nil && list.expiry.now() >= elem.expireAt
+}
+
+// skipExpired returns the first element from elem that hasn't expired, walking forward or backward.
+func skipExpired[K, V any](elem *Element[K, V], forward bool) *Element[K, V] {
+	for elem != nil && elem.expired() {
+		if forward {
+			elem = elem.next[0]
+		} else {
+			elem = elem.prev
+		}
+	}
+	return elem
+}
+
+// liveElements returns the key-value pairs of elements which haven't expired at the time of the call, and their count.
+// The elements are fixed by the time of the call, so the count matches the iterator even if some expire meanwhile.
+func (list *skipListUnSafe[K, V]) liveElements() (count int, elems iter.Seq2[K, V]) {
+	live := func(elem *Element[K, V]) bool { return true }
+	count = list.length
+	if list.expiry != nil {
+		now := list.expiry.now()
+		live = func(elem *Element[K, V]) bool { return elem.expireAt == 0 || elem.expireAt > now }
+		count = 0
+		for elem := list.next[0]; elem != nil; elem = elem.next[0] {
+			if live(elem) {
+				count++
+			}
+		}
+	}
+	return count, func(yield func(K, V) bool) {
+		for elem := list.next[0]; elem != nil; elem = elem.next[0] {
+			if live(elem) && !yield(elem.key, elem.Value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package skiplist
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now atomic.Int64
+}
+
+func (c *fakeClock) Now() time.Time {
+	return time.Unix(0, c.now.Load())
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now.Add(int64(d))
+}
+
+func TestExpiry(t *testing.T) {
+	a := assert.New(t)
+	clock := &fakeClock{}
+	expired := map[int]string{}
+	list := New[int, string](NumberComparator[int], WithExpiry(clock.Now, func(key int, value string) {
+		expired[key] = value
+	}))
+	for i := 0; i < 10; i++ {
+		list.Set(i, "forever")
+	}
+	for i := 10; i < 20; i++ {
+		list.SetWithTTL(i, "short", time.Duration(i%2+1)*time.Second)
+	}
+	list.SetWithTTL(-1, "short", time.Second)
+	list.SetWithTTL(20, "short", time.Second)
+	list.SetWithTTL(5, "renewed", time.Second)
+	list.Set(11, "forever")
+	a.Equal(22, list.Len())
+
+	clock.Advance(time.Second)
+	// even keys from 10 and the bounds expired.
+	a.Nil(list.Get(10))
+	a.Equal(map[int]string{10: "short"}, expired)
+	a.Equal(21, list.Len())
+	_, ok := list.GetValue(12)
+	a.False(ok)
+	a.Equal(15, list.Find(14).Key())
+	a.Equal(11, list.Find(12).Prev().Key())
+	a.Equal(0, list.Front().Key())
+	a.Equal(19, list.Back().Key())
+	a.Equal(13, list.Floor(14).Key())
+	a.Equal(13, list.Lower(15).Key())
+	a.Equal(17, list.Higher(15).Key())
+	a.Equal([]int{0, 1, 2, 3, 4, 6, 7, 8, 9, 11, 13, 15, 17, 19}, list.Keys())
+	var backward []int
+	for k := range list.Backward() {
+		backward = append(backward, k)
+	}
+	a.Equal([]int{19, 17, 15, 13, 11, 9, 8, 7, 6, 4, 3, 2, 1, 0}, backward)
+	var ranged []int
+	list.ReverseRange(16, 12, func(elem *Element[int, string]) bool {
+		ranged = append(ranged, elem.Key())
+		return true
+	})
+	a.Equal([]int{15, 13}, ranged)
+
+	a.Nil(list.Remove(14))
+	a.Contains(expired, 14)
+	elem, loaded := list.GetOrSet(16, "new")
+	a.False(loaded)
+	a.Equal("new", elem.Value)
+	a.Contains(expired, 16)
+
+	removed := list.RemoveExpired()
+	a.Equal(4, removed)
+	a.Equal(map[int]string{-1: "short", 5: "renewed", 10: "short", 12: "short", 14: "short", 16: "short", 18: "short", 20: "short"}, expired)
+	a.Equal(15, list.Len())
+	assertSanity(a, list)
+
+	clock.Advance(time.Second)
+	a.Equal(4, list.RemoveExpired())
+	a.Equal([]int{0, 1, 2, 3, 4, 6, 7, 8, 9, 11, 16}, list.Keys())
+	a.Equal(11, list.Len())
+	a.Equal(0, list.RemoveExpired())
+	assertSanity(a, list)
+
+	// ttl not greater than 0 never expires, Set clears the ttl.
+	list.SetWithTTL(30, "forever", 0)
+	list.SetWithTTL(31, "short", time.Second)
+	list.Set(31, "forever")
+	clock.Advance(time.Hour)
+	a.Equal(0, list.RemoveExpired())
+	a.Equal("forever", list.MustGetValue(31))
+}
+
+func TestExpiryDuplicates(t *testing.T) {
+	a := assert.New(t)
+	clock := &fakeClock{}
+	list := New[int, int](NumberComparator[int], WithDuplicates(), WithExpiry[int, int](clock.Now, nil))
+	list.SetWithTTL(1, 1, time.Second)
+	list.Set(1, 2)
+	list.SetWithTTL(1, 3, time.Second)
+	list.Set(2, 4)
+	clock.Advance(time.Second)
+	a.Equal(2, list.Get(1).Value)
+	a.Equal(2, list.Len())
+	a.Len(list.GetAll(1), 1)
+	assertSanity(a, list)
+}
+
+func TestExpiryConcurrentReclaim(t *testing.T) {
+	a := assert.New(t)
+	clock := &fakeClock{}
+	var count atomic.Int64
+	list := New[int, int](NumberComparator[int], WithMutex(), WithExpiry(clock.Now, func(int, int) {
+		count.Add(1)
+	}))
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := g; i < 1000; i += 4 {
+				list.SetWithTTL(i, i, time.Duration(i%2+1)*time.Second)
+				list.Get(i - 10)
+			}
+		}()
+	}
+	wg.Wait()
+	a.Equal(1000, list.Len())
+	a.Equal(int64(0), count.Load())
+
+	// Get reclaims the elements due at the fixed deadline, RemoveExpired the rest.
+	clock.Advance(time.Second)
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := g; i < 1000; i += 4 {
+				list.Get(i)
+			}
+		}()
+	}
+	wg.Wait()
+	a.Equal(500, list.Len())
+	a.Equal(int64(500), count.Load())
+	clock.Advance(time.Second)
+	a.Equal(500, list.RemoveExpired())
+	a.Equal(int64(1000), count.Load())
+}
+
+func TestSweeper(t *testing.T) {
+	a := assert.New(t)
+	clock := &fakeClock{}
+	var count atomic.Int64
+	list := New[int, int](NumberComparator[int], WithMutex(), WithExpiry(clock.Now, func(int, int) {
+		count.Add(1)
+	}))
+	for i := 0; i < 1000; i++ {
+		list.SetWithTTL(i, i, time.Second)
+	}
+	stop := StartSweeper(list, time.Millisecond)
+	clock.Advance(time.Second)
+	a.Eventually(func() bool { return list.Len() == 0 }, 10*time.Second, time.Millisecond)
+	stop()
+	stop()
+	a.Equal(int64(1000), count.Load())
+}
+
+func TestExpiryDeadlines(t *testing.T) {
+	a := assert.New(t)
+	clock := &fakeClock{}
+	list := New[int, int](NumberComparator[int], WithExpiry[int, int](clock.Now, nil))
+	for i := 0; i < 100; i++ {
+		list.SetWithTTL(1, i, time.Duration(i+1)*time.Second)
+		list.SetWithTTL(2, i, time.Second)
+	}
+	inner, _ := unsafeListOf(list)
+	e := inner.expiry
+	// renewed elements keep a single deadline.
+	a.Len(e.deadlines, 2)
+	list.Remove(2)
+	a.Len(e.deadlines, 1)
+	clock.Advance(99 * time.Second)
+	a.Equal(0, list.RemoveExpired())
+	clock.Advance(time.Second)
+	a.Equal(1, list.RemoveExpired())
+	a.Empty(e.deadlines)
+	a.Empty(e.entries)
+
+	a.PanicsWithError("skiplist: StartSweeper needs a list created WithMutex or WithLockFree", func() { StartSweeper(list, time.Second) })
+}
+
+func TestExpirySnapshot(t *testing.T) {
+	a := assert.New(t)
+	clock := &fakeClock{}
+	codec := WithCodec[int, string](NumberCodec[int]{}, BytesCodec[string]{})
+	list := New[int, string](NumberComparator[int], codec, WithExpiry[int, string](clock.Now, nil))
+	list.Set(1, "a")
+	list.SetWithTTL(2, "b", time.Second)
+	list.Set(3, "c")
+	clock.Advance(time.Second)
+	a.Equal(3, list.Len())
+
+	// expired elements which aren't reclaimed yet are not written.
+	var buf bytes.Buffer
+	_, err := list.WriteTo(&buf)
+	a.NoError(err)
+	loaded := New[int, string](NumberComparator[int], codec)
+	_, err = loaded.ReadFrom(&buf)
+	a.NoError(err)
+	a.Equal([]int{1, 3}, loaded.Keys())
+
+	n, err := list.Flush(&buf)
+	a.NoError(err)
+	table, err := OpenTable[int, string](bytes.NewReader(buf.Bytes()), n, NumberComparator[int], NumberCodec[int]{}, BytesCodec[string]{})
+	a.NoError(err)
+	a.Equal(2, table.Len())
+	_, ok, err := table.Get(2)
+	a.NoError(err)
+	a.False(ok)
+}
+
+func TestExpiryReadFrom(t *testing.T) {
+	a := assert.New(t)
+	clock := &fakeClock{}
+	codec := WithCodec[int, string](NumberCodec[int]{}, BytesCodec[string]{})
+	var expired []int
+	list := New[int, string](NumberComparator[int], codec, WithExpiry(clock.Now, func(key int, _ string) {
+		expired = append(expired, key)
+	}))
+	for i := 0; i < 10; i++ {
+		list.SetWithTTL(i, "ttl", time.Second)
+	}
+
+	other := New[int, string](NumberComparator[int], codec)
+	for i := 5; i < 15; i++ {
+		other.Set(i, "loaded")
+	}
+	var buf bytes.Buffer
+	_, err := other.WriteTo(&buf)
+	a.NoError(err)
+	_, err = list.ReadFrom(&buf)
+	a.NoError(err)
+	sl, _ := unsafeListOf(list)
+	a.Empty(sl.expiry.entries)
+	a.Empty(sl.expiry.deadlines)
+
+	// loaded elements don't expire by the deadlines of the replaced ones.
+	clock.Advance(time.Minute)
+	a.Equal(0, list.RemoveExpired())
+	a.Empty(expired)
+	a.Equal(10, list.Len())
+	list.SetWithTTL(20, "ttl", time.Second)
+	clock.Advance(time.Minute)
+	a.Equal(1, list.RemoveExpired())
+	a.Equal([]int{20}, expired)
+	assertSanity(a, list)
+}
@@ -1,6 +1,6 @@
 module github.com/ironpark/skiplist
 
-go 1.18
+go 1.23
 
 require (
 	github.com/onsi/gomega v1.24.2
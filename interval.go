@@ -0,0 +1,284 @@
+package skiplist
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Interval is a closed interval [Lo, Hi].
+type Interval[K any] struct {
+	Lo, Hi K
+}
+
+// IntervalList is an interval skip list, which finds the intervals containing a point or overlapping a range
+// in O(log(N) + M) where M is the number of intervals found.
+//
+// Nodes are the endpoints of the intervals. Like Hanson's interval skip list, each interval marks the highest edges
+// of the towers which together cover the interval exactly, and every node keeps the intervals marking an edge from or to it.
+// A point query collects the markers of the edges it passes over while searching the point.
+//
+// IntervalList is not safe for concurrent use.
+type IntervalList[K, V any] struct {
+	towers[K, intervalData[K, V]]
+	length int
+}
+
+type intervalData[K, V any] struct {
+	markers []intervalSet[K, V] // markers[i] are the intervals marking the edge to next[i].
+	eq      intervalSet[K, V]   // intervals marking an edge from or to this node, or ending at this node.
+	starts  []*intervalEntry[K, V]
+	refs    int // number of interval endpoints at this node.
+}
+
+type intervalEntry[K, V any] struct {
+	lo, hi *tower[K, intervalData[K, V]]
+	value  V
+	edges  []intervalEdge[K, V]
+}
+
+type intervalEdge[K, V any] struct {
+	node  *tower[K, intervalData[K, V]]
+	level int
+}
+
+type intervalSet[K, V any] map[*intervalEntry[K, V]]struct{}
+
+func (s *intervalSet[K, V]) add(entry *intervalEntry[K, V]) {
+	if *s == nil {
+		*s = make(intervalSet[K, V])
+	}
+	(*s)[entry] = struct{}{}
+}
+
+// NewIntervalList creates a new interval skip list with comparable to compare endpoints.
+// Only WithMaxLevel and WithProbability are used, other options are ignored.
+func NewIntervalList[K, V any](comparable Comparable[K], options ...Option) *IntervalList[K, V] {
+	list := &IntervalList[K, V]{
+		towers: newTowers[K, intervalData[K, V]](comparable, newOptions(options)),
+	}
+	list.head.data.markers = make([]intervalSet[K, V], list.maxLevel())
+	return list
+}
+
+// Len returns interval count in this list.
+//
+// The complexity is O(1).
+func (list *IntervalList[K, V]) Len() int {
+	return list.length
+}
+
+// Set sets value for the interval [lo, hi].
+// If the interval exists, updates its value.
+// Panics if lo is greater than hi.
+//
+// The complexity is O((K+1)*log(N)) where K is the number of intervals marking the edges split by new endpoints.
+func (list *IntervalList[K, V]) Set(lo, hi K, value V) {
+	if list.comparable(lo, hi) > 0 {
+		panic(fmt.Errorf("skiplist: interval lower bound `%v` is greater than upper bound `%v`", lo, hi))
+	}
+	if entry := list.entry(lo, hi); entry != nil {
+		entry.value = value
+		return
+	}
+	entry := &intervalEntry[K, V]{value: value}
+	entry.lo = list.acquire(lo)
+	entry.hi = list.acquire(hi)
+	entry.lo.data.starts = append(entry.lo.data.starts, entry)
+	list.place(entry)
+	list.length++
+}
+
+// GetValue returns value of the interval [lo, hi].
+//
+// The complexity is O(log(N)).
+func (list *IntervalList[K, V]) GetValue(lo, hi K) (val V, ok bool) {
+	if entry := list.entry(lo, hi); entry != nil {
+		return entry.value, true
+	}
+	return
+}
+
+// Remove removes the interval [lo, hi].
+// Returns the removed value, ok is false if the interval is not found.
+//
+// The complexity is O((K+1)*log(N)) where K is the number of intervals marking the edges of removed endpoints.
+func (list *IntervalList[K, V]) Remove(lo, hi K) (val V, ok bool) {
+	entry := list.entry(lo, hi)
+	if entry == nil {
+		return
+	}
+	list.unplace(entry)
+	starts := entry.lo.data.starts
+	for i := range starts {
+		if starts[i] == entry {
+			starts[i] = starts[len(starts)-1]
+			starts[len(starts)-1] = nil
+			entry.lo.data.starts = starts[:len(starts)-1]
+			break
+		}
+	}
+	list.release(entry.lo)
+	list.release(entry.hi)
+	list.length--
+	return entry.value, true
+}
+
+// Containing returns an iterator over intervals containing point, in no particular order.
+//
+// The complexity is O(log(N) + M) where M is the number of intervals found.
+func (list *IntervalList[K, V]) Containing(point K) iter.Seq2[Interval[K], V] {
+	return func(yield func(Interval[K], V) bool) {
+		list.containing(point, yield)
+	}
+}
+
+// Overlapping returns an iterator over intervals overlapping [from, to], in no particular order.
+//
+// The complexity is O(log(N) + M) where M is the number of intervals found.
+func (list *IntervalList[K, V]) Overlapping(from, to K) iter.Seq2[Interval[K], V] {
+	return func(yield func(Interval[K], V) bool) {
+		if list.comparable(from, to) > 0 || !list.containing(from, yield) {
+			return
+		}
+		// the others start within (from, to].
+		for node := list.find(from, true); node != nil && list.comparable(node.key, to) <= 0; node = node.next[0] {
+			for _, entry := range node.data.starts {
+				if !yield(entry.interval(), entry.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// All returns an iterator over intervals in ascending order of lower bounds.
+func (list *IntervalList[K, V]) All() iter.Seq2[Interval[K], V] {
+	return func(yield func(Interval[K], V) bool) {
+		for node := list.head.next[0]; node != nil; node = node.next[0] {
+			for _, entry := range node.data.starts {
+				if !yield(entry.interval(), entry.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (entry *intervalEntry[K, V]) interval() Interval[K] {
+	return Interval[K]{Lo: entry.lo.key, Hi: entry.hi.key}
+}
+
+// containing yields intervals containing point, returns false if yield stopped.
+func (list *IntervalList[K, V]) containing(point K, yield func(Interval[K], V) bool) bool {
+	emit := func(set intervalSet[K, V]) bool {
+		for entry := range set {
+			if !yield(entry.interval(), entry.value) {
+				return false
+			}
+		}
+		return true
+	}
+	node := list.head
+	for i := list.maxLevel() - 1; i >= 0; i-- {
+		for next := node.next[i]; next != nil && list.comparable(next.key, point) < 0; next = node.next[i] {
+			node = next
+		}
+		if next := node.next[i]; next != nil && list.comparable(next.key, point) == 0 {
+			return emit(next.data.eq)
+		}
+		// the edge from node on level i passes over point.
+		if !emit(node.data.markers[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// entry returns the entry of interval [lo, hi], or nil.
+func (list *IntervalList[K, V]) entry(lo, hi K) *intervalEntry[K, V] {
+	node := list.find(lo, false)
+	if node == nil || list.comparable(node.key, lo) != 0 {
+		return nil
+	}
+	for _, entry := range node.data.starts {
+		if list.comparable(entry.hi.key, hi) == 0 {
+			return entry
+		}
+	}
+	return nil
+}
+
+// acquire returns the node of key, inserting it if not exists, and counts a new endpoint on it.
+func (list *IntervalList[K, V]) acquire(key K) *tower[K, intervalData[K, V]] {
+	prevs := list.search(key)
+	if node := prevs[0].next[0]; node != nil && list.comparable(node.key, key) == 0 {
+		node.data.refs++
+		return node
+	}
+	node := list.newTower(key)
+	node.data.markers = make([]intervalSet[K, V], len(node.next))
+	node.data.refs = 1
+	// the new node splits the edges below its height, intervals marking them are placed again.
+	var affected []*intervalEntry[K, V]
+	for i := range node.next {
+		for entry := range prevs[i].data.markers[i] {
+			affected = append(affected, entry)
+		}
+	}
+	for _, entry := range affected {
+		list.unplace(entry)
+	}
+	list.link(prevs, node)
+	for _, entry := range affected {
+		list.place(entry)
+	}
+	return node
+}
+
+// release uncounts an endpoint on node, and removes node if it is no longer an endpoint.
+func (list *IntervalList[K, V]) release(node *tower[K, intervalData[K, V]]) {
+	if node.data.refs--; node.data.refs > 0 {
+		return
+	}
+	prevs := list.search(node.key)
+	// the edges from and to node are merged, intervals marking them are placed again.
+	affected := make([]*intervalEntry[K, V], 0, len(node.data.eq))
+	for entry := range node.data.eq {
+		affected = append(affected, entry)
+	}
+	for _, entry := range affected {
+		list.unplace(entry)
+	}
+	list.unlink(prevs, node)
+	for _, entry := range affected {
+		list.place(entry)
+	}
+}
+
+// place marks the highest edges covering the interval of entry, from its lower bound to its upper bound.
+func (list *IntervalList[K, V]) place(entry *intervalEntry[K, V]) {
+	entry.lo.data.eq.add(entry)
+	entry.hi.data.eq.add(entry)
+	for node := entry.lo; node != entry.hi; {
+		i := len(node.next) - 1
+		for node.next[i] == nil || list.comparable(node.next[i].key, entry.hi.key) > 0 {
+			i--
+		}
+		node.data.markers[i].add(entry)
+		entry.edges = append(entry.edges, intervalEdge[K, V]{node, i})
+		node = node.next[i]
+		node.data.eq.add(entry)
+	}
+}
+
+// unplace removes all markers of entry, it must be called before the marked edges change.
+func (list *IntervalList[K, V]) unplace(entry *intervalEntry[K, V]) {
+	delete(entry.lo.data.eq, entry)
+	delete(entry.hi.data.eq, entry)
+	for _, edge := range entry.edges {
+		delete(edge.node.data.markers[edge.level], entry)
+		delete(edge.node.next[edge.level].data.eq, entry)
+	}
+	clear(entry.edges)
+	entry.edges = entry.edges[:0]
+}
@@ -0,0 +1,119 @@
+package skiplist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"iter"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func collectIntervals[V any](seq iter.Seq2[Interval[int], V]) (intervals []Interval[int]) {
+	for interval := range seq {
+		intervals = append(intervals, interval)
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		if intervals[i].Lo != intervals[j].Lo {
+			return intervals[i].Lo < intervals[j].Lo
+		}
+		return intervals[i].Hi < intervals[j].Hi
+	})
+	return
+}
+
+func TestIntervalList(t *testing.T) {
+	a := assert.New(t)
+	list := NewIntervalList[int, int](NumberComparator[int], WithMaxLevel(8))
+	expected := map[Interval[int]]int{}
+	check := func() {
+		a.Equal(len(expected), list.Len())
+		for i := 0; i < 50; i++ {
+			from := rand.Intn(220) - 10
+			to := from + rand.Intn(30)
+			var containing, overlapping []Interval[int]
+			for interval := range expected {
+				if interval.Lo <= from && from <= interval.Hi {
+					containing = append(containing, interval)
+				}
+				if interval.Lo <= to && from <= interval.Hi {
+					overlapping = append(overlapping, interval)
+				}
+			}
+			a.ElementsMatch(containing, collectIntervals(list.Containing(from)))
+			a.ElementsMatch(overlapping, collectIntervals(list.Overlapping(from, to)))
+		}
+	}
+
+	for i := 0; i < 500; i++ {
+		lo := rand.Intn(200)
+		interval := Interval[int]{lo, lo + rand.Intn(40)}
+		if i%10 == 0 {
+			interval.Hi = lo
+		}
+		list.Set(interval.Lo, interval.Hi, i)
+		expected[interval] = i
+	}
+	check()
+	for interval, v := range list.All() {
+		a.Equal(expected[interval], v)
+	}
+	var los []int
+	for interval := range list.All() {
+		los = append(los, interval.Lo)
+	}
+	a.True(sort.IntsAreSorted(los))
+
+	removed := 0
+	for interval, v := range expected {
+		if removed++; removed%2 == 0 {
+			continue
+		}
+		value, ok := list.Remove(interval.Lo, interval.Hi)
+		a.True(ok)
+		a.Equal(v, value)
+		delete(expected, interval)
+	}
+	check()
+
+	for interval := range expected {
+		list.Remove(interval.Lo, interval.Hi)
+		delete(expected, interval)
+	}
+	check()
+	a.Nil(list.head.next[0])
+}
+
+func TestIntervalListValues(t *testing.T) {
+	a := assert.New(t)
+	list := NewIntervalList[int, string](NumberComparator[int])
+	list.Set(10, 20, "a")
+	list.Set(15, 30, "b")
+	list.Set(20, 20, "c")
+	list.Set(10, 20, "d")
+	a.Equal(3, list.Len())
+	v, ok := list.GetValue(10, 20)
+	a.True(ok)
+	a.Equal("d", v)
+	_, ok = list.GetValue(10, 30)
+	a.False(ok)
+	_, ok = list.Remove(10, 30)
+	a.False(ok)
+
+	values := map[string]bool{}
+	for _, v := range list.Containing(20) {
+		values[v] = true
+	}
+	a.Equal(map[string]bool{"b": true, "c": true, "d": true}, values)
+	for range list.Overlapping(0, 100) {
+		break
+	}
+	count := 0
+	for range list.Overlapping(21, 100) {
+		count++
+	}
+	a.Equal(1, count)
+	for range list.Overlapping(100, 0) {
+		a.Fail("empty range")
+	}
+	a.Panics(func() { list.Set(2, 1, "") })
+}
@@ -0,0 +1,420 @@
+package skiplist
+
+import (
+	"iter"
+	"sync/atomic"
+	"time"
+)
+
+// LockFreeSkipList is a concurrent skip list without locks.
+// Goroutines can call any method at the same time, Set, GetValue and Remove are linearizable.
+//
+// It follows the lock-free skip list from Fraser and Herlihy-Shavit:
+// an element is removed logically by clearing its value, then marked on every level,
+// and marked elements are unlinked by later searches.
+//
+// Unlike SkipList, it doesn't expose Element because links can change at any time,
+// and it doesn't keep spans, so there are no rank queries. See WithLockFree for a SkipList backed by it.
+// Iteration is weakly consistent, it reflects some of the changes made during the iteration.
+type LockFreeSkipList[K, V any] struct {
+	state      atomic.Pointer[lockFreeState[K, V]]
+	comparable Comparable[K]
+	levels     atomic.Pointer[levelGenerator]
+	height     int // level of heads, the max level can't go beyond it.
+	clock      func() time.Time
+	onExpire   func(key K, value V)
+}
+
+// lockFreeState holds all elements of the list, Init replaces it as a whole.
+type lockFreeState[K, V any] struct {
+	head   *lockFreeNode[K, V]
+	top    atomic.Int64 // highest level of all nodes, searches start there.
+	length atomic.Int64
+	seq    atomic.Uint64
+}
+
+type lockFreeNode[K, V any] struct {
+	key   K
+	seq   uint64                           // identifies the node in elements copied from it.
+	value atomic.Pointer[lockFreeValue[V]] // nil once the node is removed.
+	next  []atomic.Pointer[lockFreeRef[K, V]]
+}
+
+// lockFreeRef is an immutable next pointer with a deletion mark, swapped atomically as a whole.
+type lockFreeRef[K, V any] struct {
+	node   *lockFreeNode[K, V]
+	marked bool
+}
+
+// lockFreeValue is an immutable value of a node, swapped atomically as a whole.
+type lockFreeValue[V any] struct {
+	value    V
+	expireAt int64 // Unix time in nanoseconds when the value expires, 0 if it never expires.
+}
+
+// NewLockFree creates a new lock-free skip list with comparable to compare keys.
+// Only WithMaxLevel and WithProbability are used, other options are ignored.
+func NewLockFree[K, V any](comparable Comparable[K], options ...Option) *LockFreeSkipList[K, V] {
+	return newLockFree[K, V](comparable, newOptions(options))
+}
+
+func newLockFree[K, V any](comparable Comparable[K], option *Options) *LockFreeSkipList[K, V] {
+	list := &LockFreeSkipList[K, V]{
+		comparable: comparable,
+		height:     max(option.maxLevel, preallocDefaultMaxLevel),
+		clock:      option.clock,
+	}
+	if list.clock == nil {
+		list.clock = time.Now
+	}
+	if option.expiry {
+		list.onExpire = callbackOf[K, V](option.onExpire, "expiry")
+	}
+	list.setLevels(option.probability, option.maxLevel)
+	list.state.Store(list.newState())
+	return list
+}
+
+func (list *LockFreeSkipList[K, V]) newState() *lockFreeState[K, V] {
+	s := &lockFreeState[K, V]{head: list.newNode(*new(K), list.height, nil)}
+	s.top.Store(1)
+	return s
+}
+
+// setLevels changes how levels of new nodes are drawn, maxLevel is limited to the height of heads.
+func (list *LockFreeSkipList[K, V]) setLevels(probability float64, maxLevel int) {
+	// the global source is safe for concurrent use.
+	levels := newLevelGenerator(probability, min(maxLevel, list.height), nil)
+	list.levels.Store(&levels)
+}
+
+// Len returns element count in this list.
+//
+// The complexity is O(1).
+func (list *LockFreeSkipList[K, V]) Len() int {
+	return int(list.state.Load().length.Load())
+}
+
+// Set sets value for the key.
+// If the key exists, updates element's value.
+//
+// The complexity is O(log(N)).
+func (list *LockFreeSkipList[K, V]) Set(key K, value V) {
+	v := &lockFreeValue[V]{value: value}
+	list.update(list.state.Load(), key, func(*lockFreeValue[V]) *lockFreeValue[V] { return v })
+}
+
+// GetValue returns value of the element with the key.
+//
+// The complexity is O(log(N)).
+func (list *LockFreeSkipList[K, V]) GetValue(key K) (val V, ok bool) {
+	if node, v := list.ceiling(list.state.Load(), key, false, false); node != nil && list.comparable(node.key, key) == 0 {
+		return v.value, true
+	}
+	return
+}
+
+// Remove removes the element with the key.
+// Returns the removed value, ok is false if the key is not found.
+//
+// The complexity is O(log(N)).
+func (list *LockFreeSkipList[K, V]) Remove(key K) (val V, ok bool) {
+	_, old, _ := list.update(list.state.Load(), key, func(*lockFreeValue[V]) *lockFreeValue[V] { return nil })
+	if old == nil {
+		return
+	}
+	return old.value, true
+}
+
+// All returns an iterator over key-value pairs from front to back.
+func (list *LockFreeSkipList[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		list.iterate(list.state.Load().head)(yield)
+	}
+}
+
+// Ascend returns an iterator over key-value pairs greater or equal to from, in ascending order.
+func (list *LockFreeSkipList[K, V]) Ascend(from K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		list.iterate(list.before(list.state.Load(), &from, false))(yield)
+	}
+}
+
+// Keys returns list of keys
+func (list *LockFreeSkipList[K, V]) Keys() (keys []K) {
+	for k := range list.All() {
+		keys = append(keys, k)
+	}
+	return
+}
+
+// Values returns list of values
+func (list *LockFreeSkipList[K, V]) Values() (values []V) {
+	for _, v := range list.All() {
+		values = append(values, v)
+	}
+	return
+}
+
+// iterate returns an iterator over key-value pairs after node.
+func (list *LockFreeSkipList[K, V]) iterate(node *lockFreeNode[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for node, v := list.after(node, false); node != nil; node, v = list.after(node, false) {
+			if !yield(node.key, v.value) {
+				return
+			}
+		}
+	}
+}
+
+// now returns the current time of the clock in nanoseconds.
+func (list *LockFreeSkipList[K, V]) now() int64 {
+	return list.clock().UnixNano()
+}
+
+// alive returns true if v hasn't expired.
+func (list *LockFreeSkipList[K, V]) alive(v *lockFreeValue[V]) bool {
+	return v.expireAt == 0 || v.expireAt > list.now()
+}
+
+// update sets the value of key to the value returned by f, or removes key if f returns nil.
+// f is called with the current value, or nil if key doesn't exist or has expired.
+// If another goroutine changes key meanwhile, f is called again with the new value.
+// Returns the node of key, and the values before and after the update, nil if there is none.
+//
+// The complexity is O(log(N)).
+func (list *LockFreeSkipList[K, V]) update(s *lockFreeState[K, V], key K, f func(old *lockFreeValue[V]) *lockFreeValue[V]) (node *lockFreeNode[K, V], old, updated *lockFreeValue[V]) {
+	var predsBuf, succsBuf [preallocDefaultMaxLevel]*lockFreeNode[K, V]
+	preds, succs := list.searchBuffers(predsBuf[:], succsBuf[:])
+	level := 0
+	for {
+		if list.find(s, key, preds, succs) {
+			node = succs[0]
+			current := node.value.Load()
+			if current == nil {
+				// node is being removed, help to unlink it and retry.
+				list.mark(node)
+				continue
+			}
+			old = current
+			if !list.alive(current) {
+				old = nil
+			}
+			if updated = f(old); updated == old {
+				return
+			}
+			if !node.value.CompareAndSwap(current, updated) {
+				continue
+			}
+			if old == nil && list.onExpire != nil {
+				// the expired value is dropped like RemoveExpired does.
+				list.onExpire(key, current.value)
+			}
+			if updated == nil {
+				s.length.Add(-1)
+				list.mark(node)
+				list.find(s, key, preds, succs)
+			}
+			return
+		}
+
+		if updated = f(nil); updated == nil {
+			return nil, nil, nil
+		}
+		if level == 0 {
+			level = list.levels.Load().randLevel()
+			if int64(level) > s.top.Load() {
+				// searches must see the new levels before node is linked on them.
+				s.raise(level)
+				continue
+			}
+		}
+		node = list.newNode(key, level, updated)
+		node.seq = s.seq.Add(1)
+		for i := range node.next {
+			node.next[i].Store(&lockFreeRef[K, V]{node: succs[i]})
+		}
+		// count node before it's visible, so a concurrent Remove never makes the length negative.
+		s.length.Add(1)
+		if !list.cas(preds[0], 0, succs[0], node) {
+			s.length.Add(-1)
+			continue
+		}
+
+		for i := 1; i < len(node.next); i++ {
+			for {
+				ref := node.next[i].Load()
+				if ref.marked {
+					// removed while linking, there's nothing left to do.
+					return node, nil, updated
+				}
+				if ref.node != succs[i] && !node.next[i].CompareAndSwap(ref, &lockFreeRef[K, V]{node: succs[i]}) {
+					continue
+				}
+				if list.cas(preds[i], i, succs[i], node) {
+					break
+				}
+				list.find(s, key, preds, succs)
+			}
+		}
+		return node, nil, updated
+	}
+}
+
+// remove removes node if its value is still v.
+// Returns false if the value changed meanwhile.
+func (list *LockFreeSkipList[K, V]) remove(s *lockFreeState[K, V], node *lockFreeNode[K, V], v *lockFreeValue[V]) bool {
+	if !node.value.CompareAndSwap(v, nil) {
+		return false
+	}
+	s.length.Add(-1)
+	list.mark(node)
+	var predsBuf, succsBuf [preallocDefaultMaxLevel]*lockFreeNode[K, V]
+	preds, succs := list.searchBuffers(predsBuf[:], succsBuf[:])
+	list.find(s, node.key, preds, succs)
+	return true
+}
+
+// raise makes searches start at level or higher.
+func (s *lockFreeState[K, V]) raise(level int) {
+	for top := s.top.Load(); int64(level) > top; top = s.top.Load() {
+		if s.top.CompareAndSwap(top, int64(level)) {
+			return
+		}
+	}
+}
+
+// find fills preds and succs with the nodes around key on each level, unlinking marked nodes on the way.
+// Returns true if succs[0] holds the key.
+func (list *LockFreeSkipList[K, V]) find(s *lockFreeState[K, V], key K, preds, succs []*lockFreeNode[K, V]) bool {
+retry:
+	pred := s.head
+	var curr *lockFreeNode[K, V]
+	for i := int(s.top.Load()) - 1; i >= 0; i-- {
+		curr = pred.next[i].Load().node
+		for curr != nil {
+			ref := curr.next[i].Load()
+			for ref.marked {
+				if !list.cas(pred, i, curr, ref.node) {
+					goto retry
+				}
+				if curr = ref.node; curr == nil {
+					break
+				}
+				ref = curr.next[i].Load()
+			}
+			if curr == nil || list.comparable(curr.key, key) >= 0 {
+				break
+			}
+			pred = curr
+			curr = ref.node
+		}
+		preds[i] = pred
+		succs[i] = curr
+	}
+	return curr != nil && list.comparable(curr.key, key) == 0
+}
+
+// before returns the last node less than key, or less or equal to key if inclusive is true, or the head.
+// If key is nil, returns the last node.
+// Unlike find, it skips marked nodes without unlinking them, so reads never write.
+func (list *LockFreeSkipList[K, V]) before(s *lockFreeState[K, V], key *K, inclusive bool) *lockFreeNode[K, V] {
+	pred := s.head
+	for i := int(s.top.Load()) - 1; i >= 0; i-- {
+		curr := pred.next[i].Load().node
+		for curr != nil {
+			ref := curr.next[i].Load()
+			for ref.marked {
+				if curr = ref.node; curr == nil {
+					break
+				}
+				ref = curr.next[i].Load()
+			}
+			if curr == nil {
+				break
+			}
+			if key != nil {
+				if c := list.comparable(curr.key, *key); c > 0 || c == 0 && !inclusive {
+					break
+				}
+			}
+			pred = curr
+			curr = ref.node
+		}
+	}
+	return pred
+}
+
+// ceiling returns the first node greater or equal to key, or greater than key if exclusive is true,
+// which isn't removed, and its value. Expired nodes are skipped unless expired is true.
+func (list *LockFreeSkipList[K, V]) ceiling(s *lockFreeState[K, V], key K, exclusive, expired bool) (*lockFreeNode[K, V], *lockFreeValue[V]) {
+	return list.after(list.before(s, &key, exclusive), expired)
+}
+
+// after returns the first node after node which isn't removed, and its value.
+// Expired nodes are skipped unless expired is true.
+func (list *LockFreeSkipList[K, V]) after(node *lockFreeNode[K, V], expired bool) (*lockFreeNode[K, V], *lockFreeValue[V]) {
+	for node = node.next[0].Load().node; node != nil; node = node.next[0].Load().node {
+		if v := node.value.Load(); v != nil && (expired || list.alive(v)) {
+			return node, v
+		}
+	}
+	return nil, nil
+}
+
+// last returns the last node less than key, or less or equal to key if inclusive is true,
+// which isn't removed and hasn't expired, and its value. If key is nil, returns the last such node.
+func (list *LockFreeSkipList[K, V]) last(s *lockFreeState[K, V], key *K, inclusive bool) (*lockFreeNode[K, V], *lockFreeValue[V]) {
+	for {
+		node := list.before(s, key, inclusive)
+		if node == s.head {
+			return nil, nil
+		}
+		if v := node.value.Load(); v != nil && list.alive(v) {
+			return node, v
+		}
+		// there are no back links, search again before node.
+		key, inclusive = &node.key, false
+	}
+}
+
+// mark marks every level of node as removed, from top to bottom.
+func (list *LockFreeSkipList[K, V]) mark(node *lockFreeNode[K, V]) {
+	for i := len(node.next) - 1; i >= 0; i-- {
+		for {
+			ref := node.next[i].Load()
+			if ref.marked || node.next[i].CompareAndSwap(ref, &lockFreeRef[K, V]{node: ref.node, marked: true}) {
+				break
+			}
+		}
+	}
+}
+
+// cas replaces the unmarked next pointer of pred on level from expected to node.
+func (list *LockFreeSkipList[K, V]) cas(pred *lockFreeNode[K, V], level int, expected, node *lockFreeNode[K, V]) bool {
+	ref := pred.next[level].Load()
+	if ref.marked || ref.node != expected {
+		return false
+	}
+	return pred.next[level].CompareAndSwap(ref, &lockFreeRef[K, V]{node: node})
+}
+
+// searchBuffers returns buffers for find, allocating only if heads are higher than the preallocated buffers.
+func (list *LockFreeSkipList[K, V]) searchBuffers(preds, succs []*lockFreeNode[K, V]) ([]*lockFreeNode[K, V], []*lockFreeNode[K, V]) {
+	if list.height > len(preds) {
+		return make([]*lockFreeNode[K, V], list.height), make([]*lockFreeNode[K, V], list.height)
+	}
+	return preds[:list.height], succs[:list.height]
+}
+
+func (list *LockFreeSkipList[K, V]) newNode(key K, level int, value *lockFreeValue[V]) *lockFreeNode[K, V] {
+	node := &lockFreeNode[K, V]{
+		key:  key,
+		next: make([]atomic.Pointer[lockFreeRef[K, V]], level),
+	}
+	node.value.Store(value)
+	for i := range node.next {
+		node.next[i].Store(&lockFreeRef[K, V]{})
+	}
+	return node
+}
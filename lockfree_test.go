@@ -0,0 +1,292 @@
+package skiplist
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockFreeSkipList(t *testing.T) {
+	a := assert.New(t)
+	list := NewLockFree[int, int](NumberComparator[int])
+	expected := map[int]int{}
+	for i := 0; i < 2000; i++ {
+		k := rand.Intn(500)
+		if rand.Intn(3) == 0 {
+			v, ok := list.Remove(k)
+			ev, eok := expected[k]
+			a.Equal(eok, ok)
+			a.Equal(ev, v)
+			delete(expected, k)
+		} else {
+			list.Set(k, i)
+			expected[k] = i
+		}
+	}
+	a.Equal(len(expected), list.Len())
+	prev := -1
+	for k, v := range list.All() {
+		a.Less(prev, k)
+		a.Equal(expected[k], v)
+		prev = k
+	}
+	for k, ev := range expected {
+		v, ok := list.GetValue(k)
+		a.True(ok)
+		a.Equal(ev, v)
+	}
+	_, ok := list.GetValue(-1)
+	a.False(ok)
+	keys := list.Keys()
+	a.Equal(len(expected), len(keys))
+	a.Equal(len(expected), len(list.Values()))
+	for k := range list.Ascend(250) {
+		a.GreaterOrEqual(k, 250)
+	}
+}
+
+func TestLockFreeSkipList_Concurrent(t *testing.T) {
+	a := assert.New(t)
+	list := NewLockFree[int, int](NumberComparator[int], WithMaxLevel(8))
+	wg := sync.WaitGroup{}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := g*1000 + i
+				list.Set(k, k)
+				if i%2 == 1 {
+					v, ok := list.Remove(k)
+					a.True(ok)
+					a.Equal(k, v)
+				}
+				list.Set(-i, i)
+				list.GetValue(-i)
+				list.Remove(-i - 1)
+			}
+		}(g)
+	}
+	wg.Wait()
+	for k := range list.Ascend(0) {
+		a.Equal(0, k%2)
+	}
+	prev := math.MinInt
+	count := 0
+	for k := range list.All() {
+		a.Less(prev, k)
+		prev = k
+		count++
+	}
+	a.Equal(count, list.Len())
+}
+
+func BenchmarkConcurrentReadWrite(b *testing.B) {
+	for _, writes := range []int{10, 50} {
+		b.Run("LockFree/"+strconv.Itoa(writes), func(b *testing.B) {
+			list := NewLockFree[int, int](NumberComparator[int])
+			benchmarkMixed(b, writes, list.Set, func(k int) { list.GetValue(k) })
+		})
+		b.Run("WithLockFree/"+strconv.Itoa(writes), func(b *testing.B) {
+			list := New[int, int](NumberComparator[int], WithLockFree())
+			benchmarkMixed(b, writes, func(k, v int) { list.Set(k, v) }, func(k int) { list.GetValue(k) })
+		})
+		b.Run("Mutex/"+strconv.Itoa(writes), func(b *testing.B) {
+			list := New[int, int](NumberComparator[int], WithMutex())
+			benchmarkMixed(b, writes, func(k, v int) { list.Set(k, v) }, func(k int) { list.GetValue(k) })
+		})
+	}
+}
+
+// benchmarkMixed runs gets and sets in parallel, writes is the percentage of sets.
+func benchmarkMixed(b *testing.B, writes int, set func(k, v int), get func(k int)) {
+	for i := 0; i < 100000; i++ {
+		set(i, i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			k := r.Intn(100000)
+			if r.Intn(100) < writes {
+				set(k, k)
+			} else {
+				get(k)
+			}
+		}
+	})
+}
+
+func TestWithLockFree(t *testing.T) {
+	a := assert.New(t)
+	codec := WithCodec[int, int](NumberCodec[int]{}, NumberCodec[int]{})
+	list := New[int, int](NumberComparator[int], WithLockFree(), codec)
+	expected := New[int, int](NumberComparator[int])
+	for i := 0; i < 3000; i++ {
+		k := rand.Intn(300)
+		switch rand.Intn(12) {
+		case 0:
+			a.Equal(expected.Remove(k) != nil, list.Remove(k) != nil)
+		case 1:
+			a.Equal(expected.RemoveRange(k, k+3), list.RemoveRange(k, k+3))
+		case 2:
+			elem, loaded := list.GetOrSet(k, i)
+			expectedElem, expectedLoaded := expected.GetOrSet(k, i)
+			a.Equal(expectedLoaded, loaded)
+			a.Equal(expectedElem.Value, elem.Value)
+		case 3:
+			f := func(old int, exists bool) (int, ComputeAction) {
+				if old%2 == 1 {
+					return 0, ComputeDelete
+				}
+				return old + 1, ComputeUpdate
+			}
+			a.Equal(expected.Compute(k, f) == nil, list.Compute(k, f) == nil)
+		case 4:
+			a.Equal(expected.CompareAndSwap(k, k, -k), list.CompareAndSwap(k, k, -k))
+		case 5:
+			if elem := list.GetByRank(k % 50); elem != nil {
+				expected.RemoveElement(expected.GetByRank(k % 50))
+				list.RemoveElement(elem)
+				list.RemoveElement(elem)
+			}
+		default:
+			a.Equal(i, list.Set(k, i).Value)
+			expected.Set(k, i)
+		}
+	}
+	a.Equal(expected.Len(), list.Len())
+	a.Equal(expected.Keys(), list.Keys())
+	a.Equal(expected.Values(), list.Values())
+	a.Equal(expected.Keys(), slices.Collect(list.KeysSeq()))
+
+	var keys []int
+	for elem := list.Back(); elem != nil; elem = elem.Prev() {
+		keys = append(keys, elem.Key())
+	}
+	slices.Reverse(keys)
+	a.Equal(expected.Keys(), keys)
+	for k := range list.Backward() {
+		a.Equal(keys[len(keys)-1], k)
+		keys = keys[:len(keys)-1]
+	}
+	for i := 0; i < list.Len(); i++ {
+		elem := list.GetByRank(i)
+		a.Equal(expected.GetByRank(i).Key(), elem.Key())
+		a.Equal(i, list.Index(elem))
+		a.Equal(i, elem.Index())
+	}
+	for i := 0; i < 100; i++ {
+		k := rand.Intn(320) - 10
+		assertSameElement(a, expected.Floor(k), list.Floor(k))
+		assertSameElement(a, expected.Lower(k), list.Lower(k))
+		assertSameElement(a, expected.Higher(k), list.Higher(k))
+		assertSameElement(a, expected.Get(k), list.Get(k))
+		a.Equal(expected.CountRange(k, k+20), list.CountRange(k, k+20))
+		var got, want []int
+		list.ReverseRange(k+20, k, func(elem *Element[int, int]) bool {
+			got = append(got, elem.Key())
+			return true
+		}, WithExclusiveTo())
+		expected.ReverseRange(k+20, k, func(elem *Element[int, int]) bool {
+			want = append(want, elem.Key())
+			return true
+		}, WithExclusiveTo())
+		a.Equal(want, got)
+	}
+
+	snapshot := list.Snapshot()
+	var buf bytes.Buffer
+	_, err := list.WriteTo(&buf)
+	a.NoError(err)
+	stale := list.Front()
+	list.Init()
+	a.Equal(0, list.Len())
+	list.RemoveElement(stale)
+	a.Equal(expected.Keys(), snapshot.Keys())
+	_, err = list.ReadFrom(&buf)
+	a.NoError(err)
+	a.Equal(expected.Keys(), list.Keys())
+	a.Equal(expected.Len(), list.Len())
+	list.Set(-1, -1)
+	a.Equal(-1, list.RemoveFront().Key())
+	a.Equal(expected.Back().Key(), list.RemoveBack().Key())
+
+	a.ErrorIs(list.Apply(NewBatch[int, int](nil).Set(1, 1)), errors.ErrUnsupported)
+	a.Panics(func() { New[int, int](NumberComparator[int], WithLockFree(), WithDuplicates()) })
+}
+
+func assertSameElement(a *assert.Assertions, expected, elem *Element[int, int]) {
+	if expected == nil {
+		a.Nil(elem)
+		return
+	}
+	if a.NotNil(elem) {
+		a.Equal(expected.Key(), elem.Key())
+		a.Equal(expected.Value, elem.Value)
+	}
+}
+
+func TestWithLockFree_Expiry(t *testing.T) {
+	a := assert.New(t)
+	clock := &fakeClock{}
+	var expired []int
+	list := New[int, int](NumberComparator[int], WithLockFree(), WithExpiry[int, int](clock.Now, func(key, value int) {
+		expired = append(expired, key)
+	}))
+	list.Set(1, 1)
+	list.SetWithTTL(2, 2, time.Second)
+	list.SetWithTTL(3, 3, 2*time.Second)
+	clock.Advance(time.Second)
+	a.Nil(list.Get(2))
+	a.Equal([]int{1, 3}, list.Keys())
+	// expired elements are counted until they are reclaimed.
+	a.Equal(3, list.Len())
+	a.Equal(1, list.RemoveExpired())
+	a.Equal([]int{2}, expired)
+	a.Equal(2, list.Len())
+	clock.Advance(time.Second)
+	list.Set(3, 30)
+	a.Equal([]int{2, 3}, expired)
+	a.Equal(30, list.MustGetValue(3))
+}
+
+func TestWithLockFree_Len(t *testing.T) {
+	list := New[int, int](NumberComparator[int], WithLockFree())
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5000; i++ {
+				list.Set(i%8, i)
+				list.Remove(i % 8)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			if list.Len() != 0 {
+				t.Errorf("length is %v after all removals", list.Len())
+			}
+			return
+		default:
+			if n := list.Len(); n < 0 {
+				t.Fatalf("negative length %v", n)
+			}
+		}
+	}
+}
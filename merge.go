@@ -0,0 +1,213 @@
+package skiplist
+
+import (
+	"cmp"
+	"container/heap"
+	"fmt"
+	"iter"
+	"slices"
+	"unsafe"
+)
+
+// MergePolicy decides which values are yielded for a key found in more than one element.
+// values holds the values of the key in list order, from the oldest list to the newest.
+// The values slice is reused after MergePolicy returns, so it must not be retained.
+type MergePolicy[K, V any] func(key K, values []V) []V
+
+// NewestWins is a MergePolicy which yields only the value of the newest list.
+func NewestWins[K, V any](_ K, values []V) []V {
+	return values[len(values)-1:]
+}
+
+// KeepAll is a MergePolicy which yields all values, from the oldest list to the newest.
+func KeepAll[K, V any](_ K, values []V) []V {
+	return values
+}
+
+// MergeIter returns an iterator over key-value pairs of all lists in key order.
+// Lists are ordered from the oldest to the newest, and for equal keys only the value of the newest list is yielded.
+// See MergeIterFunc for other policies.
+//
+// The complexity is O(N*log(L)), L is the number of lists.
+func MergeIter[K, V any](comparable Comparable[K], lists ...SkipList[K, V]) iter.Seq2[K, V] {
+	return MergeIterFunc(comparable, NewestWins[K, V], lists...)
+}
+
+// MergeIterFunc returns an iterator over key-value pairs of all lists in key order,
+// policy decides which values are yielded for equal keys.
+// Lists are ordered from the oldest to the newest and must be sorted by comparable,
+// keys of lists sorted by a different order are yielded out of order.
+// Read locks of lists created WithMutex are held during the whole iteration, so the loop body must not modify them.
+// Lists not created by New are read by their All iterators.
+// If comparable is nil, just panic.
+//
+// The complexity is O(N*log(L)), L is the number of lists.
+func MergeIterFunc[K, V any](comparable Comparable[K], policy MergePolicy[K, V], lists ...SkipList[K, V]) iter.Seq2[K, V] {
+	if comparable == nil {
+		panic(fmt.Errorf("skiplist: comparable must not be nil"))
+	}
+	return func(yield func(K, V) bool) {
+		defer rlockAll(lists)()
+		seqs := make([]iter.Seq2[K, V], len(lists))
+		for i, list := range lists {
+			if safe, ok := list.(*safeSkipList[K, V]); ok {
+				// already locked.
+				seqs[i] = safe.skipListUnSafe.All()
+			} else {
+				seqs[i] = list.All()
+			}
+		}
+		var key K
+		var values []V
+		resolve := func() bool {
+			for _, v := range policy(key, values) {
+				if !yield(key, v) {
+					return false
+				}
+			}
+			values = values[:0]
+			return true
+		}
+		stopped := false
+		mergeSeqs(comparable, seqs, func(_ int, k K, v V) bool {
+			if len(values) > 0 && comparable(key, k) != 0 && !resolve() {
+				stopped = true
+				return false
+			}
+			if len(values) == 0 {
+				key = k
+			}
+			values = append(values, v)
+			return true
+		})
+		if !stopped && len(values) > 0 {
+			resolve()
+		}
+	}
+}
+
+// rlockAll read locks the lists created WithMutex and returns the function unlocking them.
+// Each list is locked once, in address order, so concurrent calls with the lists in any order can't deadlock.
+func rlockAll[K, V any](lists []SkipList[K, V]) (unlock func()) {
+	var locked []*safeSkipList[K, V]
+	for _, list := range lists {
+		if safe, ok := list.(*safeSkipList[K, V]); ok {
+			locked = append(locked, safe)
+		}
+	}
+	slices.SortFunc(locked, func(a, b *safeSkipList[K, V]) int {
+		return cmp.Compare(uintptr(unsafe.Pointer(a)), uintptr(unsafe.Pointer(b)))
+	})
+	locked = slices.Compact(locked)
+	for _, list := range locked {
+		list.lock.RLock()
+	}
+	return func() {
+		for _, list := range locked {
+			list.lock.RUnlock()
+		}
+	}
+}
+
+// mergeHeap is a min-heap of merge cursors ordered by less.
+type mergeHeap[T any] struct {
+	cursors []T
+	less    func(a, b *T) bool
+}
+
+func (h *mergeHeap[T]) Len() int { return len(h.cursors) }
+
+func (h *mergeHeap[T]) Less(i, j int) bool { return h.less(&h.cursors[i], &h.cursors[j]) }
+
+func (h *mergeHeap[T]) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+
+func (h *mergeHeap[T]) Push(x any) { h.cursors = append(h.cursors, x.(T)) }
+
+func (h *mergeHeap[T]) Pop() any {
+	last := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return last
+}
+
+// elementCursor is the current element of one merged source.
+type elementCursor[K, V any] struct {
+	elem   *Element[K, V]
+	source int
+}
+
+// mergeElements walks lists starting from starts with Next() and calls yield for each element in key order.
+// Elements with equal keys are yielded in the order of their sources.
+// If yield returns false, merge stops.
+//
+// The complexity is O(M*log(S)), M is the number of visited elements and S is the number of sources.
+func mergeElements[K, V any](comparable Comparable[K], starts []*Element[K, V], yield func(source int, elem *Element[K, V]) bool) {
+	h := &mergeHeap[elementCursor[K, V]]{
+		less: func(a, b *elementCursor[K, V]) bool {
+			if c := comparable(a.elem.key, b.elem.key); c != 0 {
+				return c < 0
+			}
+			return a.source < b.source
+		},
+	}
+	for i, elem := range starts {
+		if elem != nil {
+			h.cursors = append(h.cursors, elementCursor[K, V]{elem: elem, source: i})
+		}
+	}
+	heap.Init(h)
+	for h.Len() > 0 {
+		top := &h.cursors[0]
+		if !yield(top.source, top.elem) {
+			return
+		}
+		if top.elem = top.elem.Next(); top.elem == nil {
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+	}
+}
+
+// seqCursor is the current pair of one merged sequence.
+type seqCursor[K, V any] struct {
+	key    K
+	value  V
+	next   func() (K, V, bool)
+	source int
+}
+
+// mergeSeqs pulls pairs from sorted seqs and calls yield for each pair in key order.
+// Pairs with equal keys are yielded in the order of their sources.
+// If yield returns false, merge stops.
+//
+// The complexity is O(M*log(S)), M is the number of visited pairs and S is the number of sources.
+func mergeSeqs[K, V any](comparable Comparable[K], seqs []iter.Seq2[K, V], yield func(source int, key K, value V) bool) {
+	h := &mergeHeap[seqCursor[K, V]]{
+		less: func(a, b *seqCursor[K, V]) bool {
+			if c := comparable(a.key, b.key); c != 0 {
+				return c < 0
+			}
+			return a.source < b.source
+		},
+	}
+	for i, seq := range seqs {
+		next, stop := iter.Pull2(seq)
+		defer stop()
+		if key, value, ok := next(); ok {
+			h.cursors = append(h.cursors, seqCursor[K, V]{key: key, value: value, next: next, source: i})
+		}
+	}
+	heap.Init(h)
+	for h.Len() > 0 {
+		top := &h.cursors[0]
+		if !yield(top.source, top.key, top.value) {
+			return
+		}
+		var ok bool
+		if top.key, top.value, ok = top.next(); !ok {
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package skiplist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestMergeIter(t *testing.T) {
+	a := assert.New(t)
+	lists := []SkipList[int, int]{
+		New[int, int](NumberComparator[int]),
+		New[int, int](NumberComparator[int], WithMutex()),
+		New[int, int](NumberComparator[int], WithDuplicates()),
+	}
+	for i := 0; i < 100; i++ {
+		lists[0].Set(i, 0)
+	}
+	for i := 0; i < 100; i += 2 {
+		lists[1].Set(i, 1)
+	}
+	for i := 0; i < 200; i += 5 {
+		lists[2].Set(i, 2)
+		lists[2].Set(i, 3)
+	}
+
+	prev := -1
+	count := 0
+	for k, v := range MergeIter(NumberComparator[int], lists...) {
+		a.Greater(k, prev)
+		prev = k
+		count++
+		switch {
+		case k%5 == 0:
+			a.Equal(3, v)
+		case k%2 == 0:
+			a.Equal(1, v)
+		default:
+			a.Equal(0, v)
+		}
+	}
+	a.Equal(120, count)
+
+	var keys, values []int
+	for k, v := range MergeIterFunc(NumberComparator[int], KeepAll[int, int], lists...) {
+		if k > 10 {
+			break
+		}
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	a.Equal([]int{0, 0, 0, 0, 1, 2, 2, 3, 4, 4, 5, 5, 5, 6, 6, 7, 8, 8, 9, 10, 10, 10, 10}, keys)
+	a.Equal([]int{0, 1, 2, 3, 0, 0, 1, 0, 0, 1, 0, 2, 3, 0, 1, 0, 0, 1, 0, 0, 1, 2, 3}, values)
+
+	sum := func(_ int, values []int) []int {
+		total := 0
+		for _, v := range values {
+			total += v
+		}
+		return []int{total}
+	}
+	merged := map[int]int{}
+	for k, v := range MergeIterFunc(NumberComparator[int], sum, lists...) {
+		merged[k] = v
+	}
+	a.Len(merged, 120)
+	a.Equal(6, merged[10])
+	a.Equal(5, merged[15])
+	a.Equal(5, merged[150])
+	a.Equal(1, merged[2])
+
+	for range MergeIter[int, int](NumberComparator[int]) {
+		a.Fail("no lists")
+	}
+	a.PanicsWithError("skiplist: comparable must not be nil", func() { MergeIter[int, int](nil, lists...) })
+	lists[1].Set(1000, 1)
+}
+
+// foreignList is an implementation of SkipList not created by New.
+type foreignList struct {
+	SkipList[int, int]
+}
+
+func TestMergeIterLists(t *testing.T) {
+	a := assert.New(t)
+	safe := New[int, int](NumberComparator[int], WithMutex())
+	other := New[int, int](NumberComparator[int], WithMutex())
+	lockFree := New[int, int](NumberComparator[int], WithLockFree())
+	foreign := foreignList{New[int, int](NumberComparator[int])}
+	for i := 0; i < 10; i++ {
+		safe.Set(i, 0)
+		other.Set(i, 1)
+		lockFree.Set(i*2, 2)
+		foreign.Set(i*3, 3)
+	}
+	var keys, values []int
+	for k, v := range MergeIter[int, int](NumberComparator[int], safe, lockFree, foreign) {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	a.Equal([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 12, 14, 15, 16, 18, 21, 24, 27}, keys)
+	a.Equal([]int{3, 0, 2, 3, 2, 0, 3, 0, 2, 3, 2, 3, 2, 3, 2, 3, 3, 3, 3}, values)
+
+	// lists not created by New are merged as well.
+	keys = nil
+	for k := range MergeIter[int, int](NumberComparator[int], foreign) {
+		keys = append(keys, k)
+	}
+	a.Equal([]int{0, 3, 6, 9, 12, 15, 18, 21, 24, 27}, keys)
+
+	// the same list twice and lists in opposite orders don't deadlock with writers.
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				lists := []SkipList[int, int]{safe, other, safe}
+				if g%2 == 1 {
+					lists = []SkipList[int, int]{other, safe}
+				}
+				for range MergeIter(NumberComparator[int], lists...) {
+					runtime.Gosched()
+				}
+				safe.Set(i, i)
+				other.Set(i, i)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,11 +1,27 @@
 package skiplist
 
+import (
+	"fmt"
+	"time"
+)
+
 // Options holds Skiplist's options
 type Options struct {
 	maxLevel    int
 	probability float64
 	useLock     bool
+	lockFree    bool
 	usePool     bool
+	duplicates  bool
+	tombstones  bool
+	keyCodec    any
+	valueCodec  any
+	expiry      bool
+	clock       func() time.Time
+	onExpire    any
+	capacity    int
+	eviction    EvictionPolicy
+	onEvict     any
 }
 
 // Option is a function used to set Options
@@ -18,6 +34,21 @@ func WithMutex() Option {
 	}
 }
 
+// WithLockFree makes New return a SkipList backed by a LockFreeSkipList, which is safe for concurrent use without locks.
+// Set, Get and Remove are linearizable, iterations and all other reads are weakly consistent.
+//
+// Elements are copies of the list's entries: changing their Value doesn't change the list,
+// and Next and Prev search the list again. There are no spans, so Index, GetByRank, RemoveByRank
+// and CountRange walk the list in O(N). Snapshot, WriteTo and Flush copy the list while it's being changed,
+// Compute calls f again if the key changes meanwhile, and Apply returns an error wrapping errors.ErrUnsupported
+// since a batch can't be applied atomically without a lock.
+// It can't be combined with WithDuplicates, WithCapacity or WithTombstones, WithMutex and WithPool are ignored.
+func WithLockFree() Option {
+	return func(option *Options) {
+		option.lockFree = true
+	}
+}
+
 // WithMaxLevel sets max level of Skiplist
 func WithMaxLevel(maxLevel int) Option {
 	return func(option *Options) {
@@ -32,9 +63,180 @@ func WithProbability(probability float64) Option {
 	}
 }
 
+// WithDuplicates allows multiple elements with the same key.
+// Set always inserts a new element after existing elements with equal key.
+func WithDuplicates() Option {
+	return func(option *Options) {
+		option.duplicates = true
+	}
+}
+
+// WithTombstones makes the list remember removed keys, and Flush writes them as deletion markers,
+// so MergeTables doesn't yield the keys from older tables. Remove records the key even if it's not in the list.
+// Expired elements are deleted as well, but elements evicted by WithCapacity are not.
+// Setting the key again drops its tombstone, Init and ReadFrom drop all of them, e.g. once the list is flushed.
+// It can't be combined with WithDuplicates.
+func WithTombstones() Option {
+	return func(option *Options) {
+		option.tombstones = true
+	}
+}
+
+// WithCodec sets codecs used by WriteTo and ReadFrom of Skiplist.
+// Codec types must match the key and value types of the list.
+func WithCodec[K, V any](keyCodec Codec[K], valueCodec Codec[V]) Option {
+	return func(option *Options) {
+		option.keyCodec = keyCodec
+		option.valueCodec = valueCodec
+	}
+}
+
+// WithExpiry sets the clock and the callback of elements set by SetWithTTL.
+// clock returns the current time, time.Now is used if it's nil.
+// onExpire is called with the key and value of each expired element when it's reclaimed, it may be nil.
+// onExpire is called while the list is locked, so it must not call the list.
+func WithExpiry[K, V any](clock func() time.Time, onExpire func(key K, value V)) Option {
+	return func(option *Options) {
+		option.expiry = true
+		option.clock = clock
+		option.onExpire = onExpire
+	}
+}
+
+// WithCapacity limits the list to n elements.
+// When an insert makes the list longer than n, elements are evicted by policy.
+// With EvictSmallest and EvictLargest the new element itself may be evicted, then Set, SetWithTTL, GetOrSet
+// and Compute return nil.
+func WithCapacity(n int, policy EvictionPolicy) Option {
+	return func(option *Options) {
+		option.capacity = n
+		option.eviction = policy
+	}
+}
+
+// WithOnEvict sets a callback called with the key and value of each element evicted by WithCapacity.
+// onEvict is called while the list is locked, so it must not call the list.
+func WithOnEvict[K, V any](onEvict func(key K, value V)) Option {
+	return func(option *Options) {
+		option.onEvict = onEvict
+	}
+}
+
 // WithPool sets probability of Skiplist
 func WithPool() Option {
 	return func(option *Options) {
 		option.usePool = true
 	}
 }
+
+// newOptions applies options over the defaults, a maxLevel not greater than 0 falls back to DefaultMaxLevel.
+func newOptions(options []Option) *Options {
+	option := &Options{
+		maxLevel:    DefaultMaxLevel,
+		probability: DefaultProbability,
+	}
+	for _, o := range options {
+		o(option)
+	}
+	if option.maxLevel <= 0 {
+		option.maxLevel = DefaultMaxLevel
+	}
+	return option
+}
+
+// codecsOf returns the codecs given by WithCodec, panics if their types don't match K and V.
+func codecsOf[K, V any](option *Options) (keyCodec Codec[K], valueCodec Codec[V]) {
+	var ok bool
+	if option.keyCodec != nil {
+		if keyCodec, ok = option.keyCodec.(Codec[K]); !ok {
+			panic(fmt.Errorf("skiplist: key codec %T doesn't match key type", option.keyCodec))
+		}
+	}
+	if option.valueCodec != nil {
+		if valueCodec, ok = option.valueCodec.(Codec[V]); !ok {
+			panic(fmt.Errorf("skiplist: value codec %T doesn't match value type", option.valueCodec))
+		}
+	}
+	return
+}
+
+// callbackOf returns the callback given by an option, panics if its type doesn't match K and V.
+// what names the callback in the panic.
+func callbackOf[K, V any](callback any, what string) func(key K, value V) {
+	if callback == nil {
+		return nil
+	}
+	f, ok := callback.(func(key K, value V))
+	if !ok {
+		panic(fmt.Errorf("skiplist: %v callback %T doesn't match key and value types", what, callback))
+	}
+	return f
+}
+
+// RangeOptions holds bounds of a range query
+type RangeOptions struct {
+	exclusiveFrom bool
+	exclusiveTo   bool
+}
+
+// RangeOption is a function used to set RangeOptions
+type RangeOption func(option *RangeOptions)
+
+// WithExclusiveFrom excludes the lower bound from a range query
+func WithExclusiveFrom() RangeOption {
+	return func(option *RangeOptions) {
+		option.exclusiveFrom = true
+	}
+}
+
+// WithExclusiveTo excludes the upper bound from a range query
+func WithExclusiveTo() RangeOption {
+	return func(option *RangeOptions) {
+		option.exclusiveTo = true
+	}
+}
+
+func newRangeOptions(options []RangeOption) *RangeOptions {
+	option := &RangeOptions{}
+	for _, o := range options {
+		o(option)
+	}
+	return option
+}
+
+// DefaultBlockSize is the default size of a data block written by Flush
+const DefaultBlockSize = 4096
+
+// TableOptions holds options of a sorted table written by Flush
+type TableOptions struct {
+	blockSize       int
+	bloomBitsPerKey int
+}
+
+// TableOption is a function used to set TableOptions
+type TableOption func(option *TableOptions)
+
+// WithBlockSize sets the size of data blocks, a block is closed once it reaches size bytes
+func WithBlockSize(size int) TableOption {
+	return func(option *TableOptions) {
+		option.blockSize = size
+	}
+}
+
+// WithBloomFilter adds a bloom filter using bitsPerKey bits per key, 10 bits give about 1% false positives
+func WithBloomFilter(bitsPerKey int) TableOption {
+	return func(option *TableOptions) {
+		option.bloomBitsPerKey = bitsPerKey
+	}
+}
+
+func newTableOptions(options []TableOption) *TableOptions {
+	option := &TableOptions{blockSize: DefaultBlockSize}
+	for _, o := range options {
+		o(option)
+	}
+	if option.blockSize <= 0 {
+		option.blockSize = DefaultBlockSize
+	}
+	return option
+}
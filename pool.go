@@ -18,6 +18,7 @@ func newElementPool[K, V any]() *elementPool[K, V] {
 			New: func() interface{} {
 				return &elementHeader[K, V]{
 					next: make([]*Element[K, V], 0, DefaultMaxLevel),
+					span: make([]int, 0, DefaultMaxLevel),
 				}
 			},
 		},
@@ -26,7 +27,12 @@ func newElementPool[K, V any]() *elementPool[K, V] {
 
 func (f *elementPool[K, V]) Get(list SkipList[K, V], level int, key K, value V) (element *Element[K, V]) {
 	header := f.pool.Get().(*elementHeader[K, V])
+	if cap(header.next) < level {
+		header.next = make([]*Element[K, V], 0, level)
+		header.span = make([]int, 0, level)
+	}
 	header.next = header.next[:level]
+	header.span = header.span[:level]
 	return &Element[K, V]{
 		list:          list,
 		Value:         value,
@@ -41,6 +47,7 @@ func (f *elementPool[K, V]) Put(element *Element[K, V]) {
 	next := element.next
 	for i := range next {
 		next[i] = nil
+		element.span[i] = 0
 	}
 	f.pool.Put(element.elementHeader)
 	return
@@ -59,7 +66,8 @@ func (f *fakePool[K, V]) Get(list SkipList[K, V], level int, key K, value V) (el
 		Value: value,
 		key:   key,
 		elementHeader: &elementHeader[K, V]{
-			make([]*Element[K, V], level),
+			next: make([]*Element[K, V], level),
+			span: make([]int, level),
 		},
 	}
 }
@@ -68,5 +76,6 @@ func (f *fakePool[K, V]) Put(element *Element[K, V]) {
 	element.list = nil
 	element.prev = nil
 	element.next = nil
+	element.span = nil
 	return
 }
@@ -0,0 +1,193 @@
+package skiplist
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Partitioner returns the shard index in [0, shards) for key.
+type Partitioner[K any] func(key K, shards int) int
+
+// RangePartitioner creates a Partitioner splitting keys by sorted bounds.
+// Keys less than bounds[0] go to shard 0, keys in [bounds[i-1], bounds[i]) go to shard i,
+// and the rest go to the last shard.
+// Use len(bounds)+1 shards for it.
+func RangePartitioner[K any](comparable Comparable[K], bounds ...K) Partitioner[K] {
+	return func(key K, shards int) int {
+		lo, hi := 0, len(bounds)
+		for lo < hi {
+			mid := int(uint(lo+hi) >> 1)
+			if comparable(key, bounds[mid]) >= 0 {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		return min(lo, shards-1)
+	}
+}
+
+// Sharded is a goroutine-safe skip list partitioned into independent shards.
+// Each shard has its own lock, so writes to different shards don't block each other.
+// Ordered reads merge all shards, while holding read locks of every shard.
+type Sharded[K, V any] struct {
+	shards     []*safeSkipList[K, V]
+	comparable Comparable[K]
+	partition  Partitioner[K]
+}
+
+// NewSharded creates a new sharded skip list with n shards.
+// partition chooses the shard for each key, options apply to every shard.
+// Every shard is locked WithMutex, so WithMutex is implied and WithLockFree can't be used.
+// If n is not greater than 0, partition is nil or options contain WithLockFree, just panic.
+func NewSharded[K, V any](comparable Comparable[K], n int, partition Partitioner[K], options ...Option) *Sharded[K, V] {
+	if n <= 0 {
+		panic(fmt.Errorf("skiplist: shards must be larger than 0 (current is %v)", n))
+	}
+	if partition == nil {
+		panic(fmt.Errorf("skiplist: partitioner must not be nil"))
+	}
+	if newOptions(options).lockFree {
+		panic(fmt.Errorf("skiplist: shards can't be created WithLockFree"))
+	}
+	options = append(options[:len(options):len(options)], WithMutex())
+	sharded := &Sharded[K, V]{
+		shards:     make([]*safeSkipList[K, V], n),
+		comparable: comparable,
+		partition:  partition,
+	}
+	for i := range sharded.shards {
+		sharded.shards[i] = New[K, V](comparable, options...).(*safeSkipList[K, V])
+	}
+	return sharded
+}
+
+// Shards returns the number of shards.
+func (s *Sharded[K, V]) Shards() int {
+	return len(s.shards)
+}
+
+// Shard returns the shard i.
+func (s *Sharded[K, V]) Shard(i int) SkipList[K, V] {
+	return s.shards[i]
+}
+
+// Len returns element count of all shards.
+//
+// The complexity is O(S), S is the number of shards.
+func (s *Sharded[K, V]) Len() (length int) {
+	for _, shard := range s.shards {
+		length += shard.Len()
+	}
+	return
+}
+
+// Set sets value for the key in its shard.
+// If the key exists, updates element's value.
+// Returns the element holding the key and value.
+//
+// The complexity is O(log(N)).
+func (s *Sharded[K, V]) Set(key K, value V) (elem *Element[K, V]) {
+	return s.shardOf(key).Set(key, value)
+}
+
+// Get returns an element with the key.
+// If the key is not found, returns nil.
+//
+// The complexity is O(log(N)).
+func (s *Sharded[K, V]) Get(key K) (elem *Element[K, V]) {
+	return s.shardOf(key).Get(key)
+}
+
+// GetValue returns value of the element with the key.
+//
+// The complexity is O(log(N)).
+func (s *Sharded[K, V]) GetValue(key K) (val V, ok bool) {
+	return s.shardOf(key).GetValue(key)
+}
+
+// Remove removes an element.
+// Returns removed element pointer if found, nil if it's not found.
+//
+// The complexity is O(log(N)).
+func (s *Sharded[K, V]) Remove(key K) (elem *Element[K, V]) {
+	return s.shardOf(key).Remove(key)
+}
+
+// Keys returns list of keys of all shards in order.
+func (s *Sharded[K, V]) Keys() (keys []K) {
+	for k := range s.All() {
+		keys = append(keys, k)
+	}
+	return
+}
+
+// Values returns list of values of all shards in key order.
+func (s *Sharded[K, V]) Values() (values []V) {
+	for _, v := range s.All() {
+		values = append(values, v)
+	}
+	return
+}
+
+// All returns an iterator over key-value pairs of all shards in key order.
+// Read locks of all shards are held during the whole iteration, so the loop body must not modify the list.
+//
+// The complexity is O(N*log(S)), S is the number of shards.
+func (s *Sharded[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		s.rlock()
+		defer s.runlock()
+		starts := make([]*Element[K, V], len(s.shards))
+		for i, shard := range s.shards {
+			starts[i] = shard.skipListUnSafe.Front()
+		}
+		mergeElements(s.comparable, starts, func(_ int, elem *Element[K, V]) bool {
+			return yield(elem.key, elem.Value)
+		})
+	}
+}
+
+// Range calls f sequentially for each element of all shards with key between from and to, in key order.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// If f returns false, range stops the iteration.
+// Read locks of all shards are held during the whole iteration, so f must not modify the list.
+//
+// The complexity is O(S*log(N)+M*log(S)), M is the number of visited elements and S is the number of shards.
+func (s *Sharded[K, V]) Range(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption) {
+	option := newRangeOptions(options)
+	s.rlock()
+	defer s.runlock()
+	starts := make([]*Element[K, V], len(s.shards))
+	for i, shard := range s.shards {
+		if option.exclusiveFrom {
+			starts[i] = shard.skipListUnSafe.Higher(from)
+		} else {
+			starts[i] = shard.skipListUnSafe.Find(from)
+		}
+	}
+	mergeElements(s.comparable, starts, func(_ int, elem *Element[K, V]) bool {
+		c := s.comparable(elem.key, to)
+		if c > 0 || c == 0 && option.exclusiveTo {
+			return false
+		}
+		return f(elem)
+	})
+}
+
+func (s *Sharded[K, V]) shardOf(key K) *safeSkipList[K, V] {
+	return s.shards[s.partition(key, len(s.shards))]
+}
+
+// rlock acquires read locks of all shards in order.
+func (s *Sharded[K, V]) rlock() {
+	for _, shard := range s.shards {
+		shard.lock.RLock()
+	}
+}
+
+func (s *Sharded[K, V]) runlock() {
+	for _, shard := range s.shards {
+		shard.lock.RUnlock()
+	}
+}
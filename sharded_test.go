@@ -0,0 +1,84 @@
+package skiplist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestRangePartitioner(t *testing.T) {
+	a := assert.New(t)
+	partition := RangePartitioner(NumberComparator[int], 10, 20, 30)
+	a.Equal(0, partition(-5, 4))
+	a.Equal(1, partition(10, 4))
+	a.Equal(1, partition(19, 4))
+	a.Equal(3, partition(30, 4))
+	a.Equal(3, partition(1000, 4))
+	a.Equal(1, partition(1000, 2))
+}
+
+func TestSharded(t *testing.T) {
+	a := assert.New(t)
+	modulo := func(key int, shards int) int {
+		return (key%shards + shards) % shards
+	}
+	for _, s := range []*Sharded[int, int]{
+		NewSharded[int, int](NumberComparator[int], 4, modulo),
+		NewSharded[int, int](NumberComparator[int], 4, RangePartitioner(NumberComparator[int], 250, 500, 750)),
+	} {
+		wg := sync.WaitGroup{}
+		for g := 0; g < 4; g++ {
+			wg.Add(2)
+			go func(g int) {
+				defer wg.Done()
+				for _, i := range rand.Perm(250) {
+					s.Set(g*250+i, i)
+				}
+			}(g)
+			go func() {
+				defer wg.Done()
+				prev := -1
+				for k := range s.All() {
+					a.Less(prev, k)
+					prev = k
+				}
+			}()
+		}
+		wg.Wait()
+
+		a.Equal(4, s.Shards())
+		a.Equal(1000, s.Len())
+		for i := 0; i < 4; i++ {
+			a.Equal(250, s.Shard(i).Len())
+		}
+		keys := s.Keys()
+		a.Len(keys, 1000)
+		for i, k := range keys {
+			a.Equal(i, k)
+		}
+		a.Len(s.Values(), 1000)
+		a.Equal(7, s.Get(257).Value)
+		v, ok := s.GetValue(999)
+		a.True(ok)
+		a.Equal(249, v)
+		a.Equal(500, s.Remove(500).Key())
+		a.True(s.Remove(500) == nil)
+
+		var ranged []int
+		s.Range(495, 505, func(elem *Element[int, int]) bool {
+			ranged = append(ranged, elem.Key())
+			return true
+		}, WithExclusiveFrom())
+		a.Equal([]int{496, 497, 498, 499, 501, 502, 503, 504, 505}, ranged)
+		ranged = nil
+		s.Range(0, 1000, func(elem *Element[int, int]) bool {
+			ranged = append(ranged, elem.Key())
+			return len(ranged) < 3
+		})
+		a.Equal([]int{0, 1, 2}, ranged)
+	}
+	a.PanicsWithError("skiplist: shards can't be created WithLockFree", func() {
+		NewSharded[int, int](NumberComparator[int], 4, modulo, WithLockFree())
+	})
+}
@@ -35,7 +35,10 @@
 package skiplist
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"math"
 	"math/rand"
 	"time"
@@ -52,42 +55,85 @@ const (
 // preallocDefaultMaxLevel is a constant to alloc memory on stack when Set new element.
 const preallocDefaultMaxLevel = 48
 
-type SkipList[K, V any] interface {
-	Init() SkipList[K, V]
-	SetProbability(newProbability float64)
+// ReadView is the read-only part of SkipList.
+type ReadView[K, V any] interface {
 	Front() (front *Element[K, V])
 	Back() *Element[K, V]
 	Len() int
-	Set(key K, value V) (element *Element[K, V])
 	FindNext(start *Element[K, V], key K) (next *Element[K, V])
 	Find(key K) (elem *Element[K, V])
+	FindPrev(start *Element[K, V], key K) (prev *Element[K, V])
+	Floor(key K) (elem *Element[K, V])
+	Lower(key K) (elem *Element[K, V])
+	Higher(key K) (elem *Element[K, V])
+	Range(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption)
+	ReverseRange(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption)
 	Get(key K) (elem *Element[K, V])
+	GetAll(key K) (elems []*Element[K, V])
 	GetValue(key K) (val V, ok bool)
 	MustGetValue(key K) V
-	Remove(key K) (elem *Element[K, V])
-	RemoveFront() (front *Element[K, V])
-	RemoveBack() (back *Element[K, V])
-	RemoveElement(elem *Element[K, V])
+	CountRange(from, to K, options ...RangeOption) (count int)
 	MaxLevel() int
 	Values() (values []V)
 	Index(elem *Element[K, V]) (i int)
+	GetByRank(i int) (elem *Element[K, V])
 	Keys() (keys []K)
+	All() iter.Seq2[K, V]
+	Backward() iter.Seq2[K, V]
+	Ascend(from K) iter.Seq2[K, V]
+	Descend(from K) iter.Seq2[K, V]
+	KeysSeq() iter.Seq[K]
+	ValuesSeq() iter.Seq[V]
+	WriteTo(w io.Writer) (n int64, err error)
+	Flush(w io.Writer, options ...TableOption) (n int64, err error)
+}
+
+type SkipList[K, V any] interface {
+	ReadView[K, V]
+	Init() SkipList[K, V]
+	SetProbability(newProbability float64)
+	Set(key K, value V) (element *Element[K, V])
+	SetWithTTL(key K, value V, ttl time.Duration) (element *Element[K, V])
+	GetOrSet(key K, value V) (elem *Element[K, V], loaded bool)
+	Compute(key K, f func(old V, exists bool) (value V, action ComputeAction)) (elem *Element[K, V])
+	CompareAndSwap(key K, old, new V) (swapped bool)
+	CompareAndDelete(key K, old V) (deleted bool)
+	Remove(key K) (elem *Element[K, V])
+	RemoveAll(key K) (removed int)
+	RemoveFront() (front *Element[K, V])
+	RemoveBack() (back *Element[K, V])
+	RemoveElement(elem *Element[K, V])
+	RemoveRange(from, to K, options ...RangeOption) (removed int)
+	RemoveByRank(i int) (elem *Element[K, V])
+	RemoveExpired() (removed int)
 	SetMaxLevel(level int) (old int)
+	ReadFrom(r io.Reader) (n int64, err error)
+	View(f func(view ReadView[K, V]))
+	Snapshot() ReadView[K, V]
+	Apply(batch *Batch[K, V]) error
 }
 
 var _ = SkipList[int, int](&skipListUnSafe[int, int]{})
 
 type skipListUnSafe[K, V any] struct {
 	elementHeader[K, V]
-	probTable      []float64
+	levelGenerator
 	pool           pool[K, V]
 	comparable     Comparable[K]
 	prevNodesCache []*elementHeader[K, V]
-	rand           *rand.Rand
+	prevRanksCache []int
 
-	maxLevel int
-	length   int
-	back     *Element[K, V]
+	maxLevel   int
+	length     int
+	back       *Element[K, V]
+	duplicates bool
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+	expiry     *expiry[K, V]
+	evictor    *evictor[K, V]
+	tombstones *tombstones[K]
+	snapshots  *snapshotLog[K, V]
+	seq        uint64
 }
 
 // New creates a new skip list with comparable to compare keys.
@@ -95,45 +141,141 @@ type skipListUnSafe[K, V any] struct {
 // There are lots of pre-defined strict-typed keys like Int, Float64, String, etc.
 // We can create custom comparable by implementing Comparable interface.
 func New[K, V any](comparable Comparable[K], options ...Option) (skipList SkipList[K, V]) {
-	option := &Options{
-		maxLevel:    DefaultMaxLevel,
-		probability: DefaultProbability,
-		useLock:     false,
-		usePool:     false,
+	option := newOptions(options)
+	if option.lockFree {
+		return newLockFreeSkipList[K, V](comparable, option)
 	}
-	for _, o := range options {
-		o(option)
+	sk := newSkipListUnSafe[K, V](comparable, option)
+	if option.useLock {
+		return &safeSkipList[K, V]{
+			skipListUnSafe: sk,
+		}
 	}
-	source := rand.NewSource(time.Now().UnixNano())
+	return sk
+}
+
+// newSkipListUnSafe creates a list without lock, WithMutex and WithLockFree are ignored.
+func newSkipListUnSafe[K, V any](comparable Comparable[K], option *Options) *skipListUnSafe[K, V] {
 	sk := &skipListUnSafe[K, V]{
 		elementHeader: elementHeader[K, V]{
 			next: make([]*Element[K, V], option.maxLevel),
+			span: make([]int, option.maxLevel),
 		},
 		prevNodesCache: make([]*elementHeader[K, V], option.maxLevel),
+		prevRanksCache: make([]int, option.maxLevel),
 		pool:           newElementPool[K, V](),
-		probTable:      probabilityTable(option.probability, option.maxLevel),
+		levelGenerator: newLevelGenerator(option.probability, option.maxLevel, newRand()),
 		comparable:     comparable,
-		rand:           rand.New(source),
-		maxLevel:       DefaultMaxLevel,
+		maxLevel:       option.maxLevel,
+		duplicates:     option.duplicates,
+	}
+	sk.keyCodec, sk.valueCodec = codecsOf[K, V](option)
+	if option.expiry {
+		sk.expiry = newExpiry(option.clock, callbackOf[K, V](option.onExpire, "expiry"))
+	}
+	if option.capacity < 0 {
+		panic(fmt.Errorf("skiplist: capacity must not be negative (current is %v)", option.capacity))
+	}
+	if option.tombstones {
+		if option.duplicates {
+			panic(fmt.Errorf("skiplist: WithTombstones can't be combined with WithDuplicates"))
+		}
+		sk.tombstones = newTombstones[K](comparable, option)
+	}
+	if option.capacity > 0 {
+		sk.evictor = newEvictor(option.capacity, option.eviction, callbackOf[K, V](option.onEvict, "eviction"))
 	}
 	if option.usePool {
 		sk.pool = newElementPool[K, V]()
 	} else {
 		sk.pool = newFakePool[K, V]()
 	}
-	if option.useLock {
-		return &safeSkipList[K, V]{
-			skipListUnSafe: sk,
+	return sk
+}
+
+// FromSorted creates a new skip list holding keys and values, keys must be sorted by comparable.
+// Elements are linked directly at the back without searching, instead of calling Set for each key.
+// Returns ErrNotSorted if keys are out of order, or contain equal keys without WithDuplicates.
+//
+// The complexity is O(N).
+func FromSorted[K, V any](comparable Comparable[K], keys []K, values []V, options ...Option) (skipList SkipList[K, V], err error) {
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("skiplist: length of keys (%v) and values (%v) differ", len(keys), len(values))
+	}
+	skipList = New[K, V](comparable, options...)
+	list, ok := unsafeListOf(skipList)
+	if !ok {
+		// lists created WithLockFree have no builder, they are filled by Set.
+		for i := range keys {
+			if i > 0 && comparable(keys[i-1], keys[i]) >= 0 {
+				return nil, fmt.Errorf("%w: key at %v", ErrNotSorted, i)
+			}
+			skipList.Set(keys[i], values[i])
 		}
+		return
 	}
-	return sk
+	builder := list.newSortedBuilder()
+	for i := range keys {
+		if err = builder.append(keys[i], values[i]); err != nil {
+			return nil, fmt.Errorf("%w: key at %v", err, i)
+		}
+	}
+	builder.commit()
+	list.evict(nil)
+	return
+}
+
+// unsafeListOf returns the list without lock created by New.
+// ok is false if skipList is created WithLockFree, or isn't created by New.
+func unsafeListOf[K, V any](skipList SkipList[K, V]) (list *skipListUnSafe[K, V], ok bool) {
+	switch list := skipList.(type) {
+	case *safeSkipList[K, V]:
+		return list.skipListUnSafe, true
+	case *skipListUnSafe[K, V]:
+		return list, true
+	}
+	return nil, false
+}
+
+// comparableOf returns the Comparable of a list created by New.
+// ok is false if skipList isn't created by New.
+func comparableOf[K, V any](skipList SkipList[K, V]) (comparable Comparable[K], ok bool) {
+	if list, ok := skipList.(*lockFreeSkipList[K, V]); ok {
+		return list.core.comparable, true
+	}
+	if list, ok := unsafeListOf(skipList); ok {
+		return list.comparable, true
+	}
+	return nil, false
+}
+
+// codecsOfList returns the codecs of a list created by New, they are nil without WithCodec.
+func codecsOfList[K, V any](skipList SkipList[K, V]) (keyCodec Codec[K], valueCodec Codec[V]) {
+	if list, ok := skipList.(*lockFreeSkipList[K, V]); ok {
+		return list.keyCodec, list.valueCodec
+	}
+	if list, ok := unsafeListOf(skipList); ok {
+		return list.keyCodec, list.valueCodec
+	}
+	return nil, nil
 }
 
 // Init resets the list and discards all existing elements.
 func (list *skipListUnSafe[K, V]) Init() SkipList[K, V] {
+	list.preserveAll()
 	list.back = nil
 	list.length = 0
 	list.next = make([]*Element[K, V], len(list.next))
+	list.span = make([]int, len(list.span))
+	if list.expiry != nil {
+		list.expiry.reset()
+	}
+	if list.evictor != nil {
+		list.evictor.reset()
+	}
+	if list.tombstones != nil {
+		list.tombstones.clear()
+	}
 	return list
 }
 
@@ -156,17 +298,18 @@ func (list *skipListUnSafe[K, V]) SetProbability(newProbability float64) {
 //
 // The complexity is O(1).
 func (list *skipListUnSafe[K, V]) Front() (front *Element[K, V]) {
-	return list.next[0]
+	return skipExpired(list.next[0], true)
 }
 
 // Back returns the last element.
 //
 // The complexity is O(1).
 func (list *skipListUnSafe[K, V]) Back() *Element[K, V] {
-	return list.back
+	return skipExpired(list.back, false)
 }
 
 // Len returns element count in this list.
+// Expired elements are counted until they are reclaimed, see SetWithTTL.
 //
 // The complexity is O(1).
 func (list *skipListUnSafe[K, V]) Len() int {
@@ -175,23 +318,54 @@ func (list *skipListUnSafe[K, V]) Len() int {
 
 // Set sets value for the key.
 // If the key exists, updates element's value.
-// Returns the element holding the key and value.
+// If the list allows duplicates, always inserts a new element after existing elements with equal key.
+// Returns the element holding the key and value, or nil if the new element is evicted right away, see WithCapacity.
 //
 // The complexity is O(log(N)).
 func (list *skipListUnSafe[K, V]) Set(key K, value V) (element *Element[K, V]) {
-	prevs := list.getPrevElementNodes(key)
-	// replace
-	if element = prevs[0].next[0]; element != nil && list.comparable(element.key, key) <= 0 {
-		element.Value = value
-		return element
+	var prevs []*elementHeader[K, V]
+	if list.duplicates {
+		prevs = list.searchPrevElementNodes(key, true)
+	} else {
+		prevs = list.getPrevElementNodes(key)
+		// replace
+		if element = list.dropExpired(prevs, key); element != nil && list.comparable(element.key, key) <= 0 {
+			list.preserve(element)
+			element.Value = value
+			element.expireAt = 0
+			list.touch(element)
+			return element
+		}
 	}
-	// insert
-	nextElement := prevs[0].next[0]
+	element = list.insertElement(prevs, key, value)
+	if !list.evict(element) {
+		return nil
+	}
+	return
+}
+
+// insertElement links a new element after prevs, which must come from the latest search.
+func (list *skipListUnSafe[K, V]) insertElement(prevs []*elementHeader[K, V], key K, value V) (element *Element[K, V]) {
 	element = list.pool.Get(list, list.randLevel(), key, value)
+	list.stamp(element)
+	list.linkElement(prevs, element)
+	return
+}
 
+// linkElement links element after prevs, which must come from the latest search.
+func (list *skipListUnSafe[K, V]) linkElement(prevs []*elementHeader[K, V], element *Element[K, V]) {
+	nextElement := prevs[0].next[0]
+
+	ranks := list.prevRanksCache
 	for i := range element.next {
 		element.next[i] = prevs[i].next[i]
 		prevs[i].next[i] = element
+		// prevs[i] now reaches element, element takes over the rest of the old span.
+		element.span[i] = prevs[i].span[i] - (ranks[0] - ranks[i])
+		prevs[i].span[i] = ranks[0] - ranks[i] + 1
+	}
+	for i := len(element.next); i < list.maxLevel; i++ {
+		prevs[i].span[i]++
 	}
 	if nextElement == nil {
 		// 끝에 인서트
@@ -203,7 +377,15 @@ func (list *skipListUnSafe[K, V]) Set(key K, value V) (element *Element[K, V]) {
 		nextElement.prev = element
 	}
 	list.length++
-	return
+	if list.evictor != nil {
+		list.evictor.add(element)
+	}
+	if element.expireAt != 0 {
+		list.expiry.schedule(element)
+	}
+	if list.tombstones != nil {
+		list.tombstones.remove(element.key)
+	}
 }
 
 // FindNext returns the first element after start that is greater or equal to key.
@@ -213,6 +395,11 @@ func (list *skipListUnSafe[K, V]) Set(key K, value V) (element *Element[K, V]) {
 //
 // The complexity is O(log(N)).
 func (list *skipListUnSafe[K, V]) FindNext(start *Element[K, V], key K) (next *Element[K, V]) {
+	return skipExpired(list.findNext(start, key), true)
+}
+
+// findNext is FindNext including expired elements.
+func (list *skipListUnSafe[K, V]) findNext(start *Element[K, V], key K) (next *Element[K, V]) {
 	if list.length == 0 {
 		return
 	}
@@ -226,10 +413,10 @@ func (list *skipListUnSafe[K, V]) FindNext(start *Element[K, V], key K) (next *E
 		maxLevel = start.Level()
 
 	}
-	if list.comparable(key, list.Front().key) <= 0 {
-		return list.Front()
+	if list.comparable(key, list.next[0].key) <= 0 {
+		return list.next[0]
 	}
-	if list.comparable(key, list.Back().key) > 0 {
+	if list.comparable(key, list.back.key) > 0 {
 		return
 	}
 
@@ -240,6 +427,10 @@ func (list *skipListUnSafe[K, V]) FindNext(start *Element[K, V], key K) (next *E
 			switch list.comparable(key, next.key) {
 			case 0:
 				// key == next.key
+				if list.duplicates {
+					// keep going down to reach the first one of equal keys
+					goto Next
+				}
 				return next
 			case 1:
 				// key > next.key
@@ -263,11 +454,108 @@ func (list *skipListUnSafe[K, V]) Find(key K) (elem *Element[K, V]) {
 	return list.FindNext(nil, key)
 }
 
+// FindPrev returns the last element before start that is less or equal to key.
+// If start is less or equal to key, returns start.
+// If there is no such element, returns nil.
+// If start is nil, find element from back.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) FindPrev(start *Element[K, V], key K) (prev *Element[K, V]) {
+	if start != nil && list.comparable(start.key, key) <= 0 {
+		return skipExpired(start, false)
+	}
+	return skipExpired(list.findPrev(key, true), false)
+}
+
+// Floor returns the last element that is less or equal to key.
+// It's short hand for FindPrev(nil, key).
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) Floor(key K) (elem *Element[K, V]) {
+	return skipExpired(list.findPrev(key, true), false)
+}
+
+// Lower returns the last element that is less than key.
+// If there is no such element, returns nil.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) Lower(key K) (elem *Element[K, V]) {
+	return skipExpired(list.findPrev(key, false), false)
+}
+
+// Higher returns the first element that is greater than key.
+// If there is no such element, returns nil.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) Higher(key K) (elem *Element[K, V]) {
+	if prev := list.findPrev(key, true); prev != nil {
+		return prev.Next()
+	}
+	return list.Front()
+}
+
+// Range calls f sequentially for each element with key between from and to.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// If f returns false, range stops the iteration.
+// f must not modify the list.
+//
+// The complexity is O(log(N)+M), M is the number of visited elements.
+func (list *skipListUnSafe[K, V]) Range(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption) {
+	option := newRangeOptions(options)
+	elem := list.FindNext(nil, from)
+	if option.exclusiveFrom {
+		for elem != nil && list.comparable(elem.key, from) == 0 {
+			elem = elem.Next()
+		}
+	}
+	for ; elem != nil; elem = elem.Next() {
+		c := list.comparable(elem.key, to)
+		if c > 0 || c == 0 && option.exclusiveTo {
+			return
+		}
+		if !f(elem) {
+			return
+		}
+	}
+}
+
+// ReverseRange calls f sequentially for each element with key between from and to in reverse order.
+// from is the upper bound and to is the lower bound, iteration walks Prev() from the upper bound.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// If f returns false, range stops the iteration.
+// f must not modify the list.
+//
+// The complexity is O(log(N)+M), M is the number of visited elements.
+func (list *skipListUnSafe[K, V]) ReverseRange(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption) {
+	option := newRangeOptions(options)
+	elem := skipExpired(list.findPrev(from, !option.exclusiveFrom), false)
+	for ; elem != nil; elem = elem.Prev() {
+		c := list.comparable(elem.key, to)
+		if c < 0 || c == 0 && option.exclusiveTo {
+			return
+		}
+		if !f(elem) {
+			return
+		}
+	}
+}
+
 // Get returns an element with the key.
 // If the key is not found, returns nil.
 //
 // The complexity is O(log(N)).
 func (list *skipListUnSafe[K, V]) Get(key K) (elem *Element[K, V]) {
+	elem, expired := list.get(key)
+	if expired {
+		list.reclaim(key)
+	}
+	list.touch(elem)
+	return
+}
+
+// get returns the first element with the key that hasn't expired.
+// expired is true if expired elements with the key were skipped.
+func (list *skipListUnSafe[K, V]) get(key K) (elem *Element[K, V], expired bool) {
 	var prev = &list.elementHeader
 	var next *Element[K, V]
 
@@ -279,8 +567,23 @@ func (list *skipListUnSafe[K, V]) Get(key K) (elem *Element[K, V]) {
 		}
 	}
 
-	if next != nil && list.comparable(next.key, key) <= 0 {
-		return next
+	for ; next != nil && list.comparable(next.key, key) <= 0; next = next.next[0] {
+		if !next.expired() {
+			return next, expired
+		}
+		expired = true
+	}
+	return nil, expired
+}
+
+// GetAll returns all elements with the key in insertion order.
+// If the key is not found, returns nil.
+//
+// The complexity is O(log(N)+M), M is the number of elements with the key.
+func (list *skipListUnSafe[K, V]) GetAll(key K) (elems []*Element[K, V]) {
+	elem, _ := list.get(key)
+	for ; elem != nil && list.comparable(elem.key, key) == 0; elem = elem.Next() {
+		elems = append(elems, elem)
 	}
 	return
 }
@@ -313,30 +616,84 @@ func (list *skipListUnSafe[K, V]) MustGetValue(key K) V {
 
 // Remove removes an element.
 // Returns removed element pointer if found, nil if it's not found.
+// A list created WithTombstones records the tombstone of key even if it's not found, since older tables may have it.
 //
 // The complexity is O(log(N)).
 func (list *skipListUnSafe[K, V]) Remove(key K) (elem *Element[K, V]) {
 	prevs := list.getPrevElementNodes(key)
-	elem = prevs[0].next[0]
-	if elem == nil {
+	elem = list.dropExpired(prevs, key)
+	if elem == nil || list.comparable(elem.key, key) != 0 {
+		list.tombstone(key)
 		return nil
 	}
-	if list.comparable(elem.key, key) != 0 {
-		return nil
+	list.unlinkElement(prevs, elem)
+	return
+}
+
+// RemoveAll removes all elements with the key.
+// Returns the number of removed elements.
+//
+// The complexity is O(log(N)+M), M is the number of removed elements.
+func (list *skipListUnSafe[K, V]) RemoveAll(key K) (removed int) {
+	return list.RemoveRange(key, key)
+}
+
+// unlinkElement removes elem from the list and records its tombstone,
+// prevs must be the previous nodes of elem on each level.
+func (list *skipListUnSafe[K, V]) unlinkElement(prevs []*elementHeader[K, V], elem *Element[K, V]) {
+	list.tombstone(elem.key)
+	list.detachElement(prevs, elem)
+	list.pool.Put(elem)
+}
+
+// discardElement removes elem like RemoveElement, but without a tombstone, since elem isn't deleted by the caller.
+func (list *skipListUnSafe[K, V]) discardElement(elem *Element[K, V]) {
+	if prevs := list.prevsOf(elem); prevs != nil {
+		list.detachElement(prevs, elem)
+		list.pool.Put(elem)
 	}
+}
+
+// detachElement works like unlinkElement, but keeps elem intact, so it can be linked again by relinkElement.
+func (list *skipListUnSafe[K, V]) detachElement(prevs []*elementHeader[K, V], elem *Element[K, V]) {
+	list.preserve(elem)
 	tail := elem.next[0] == nil
 	if elem.next[0] != nil && elem.next[0].prev != nil {
 		elem.next[0].prev = elem.prev
 	}
-	for k, v := range elem.next {
-		prevs[k].next[k] = v
+	for i := 0; i < list.maxLevel; i++ {
+		if i < len(elem.next) {
+			prevs[i].span[i] += elem.span[i] - 1
+			prevs[i].next[i] = elem.next[i]
+		} else {
+			prevs[i].span[i]--
+		}
 	}
 	if tail {
 		list.back = elem.prev
 	}
 	list.length--
-	list.pool.Put(elem)
-	return
+	if list.evictor != nil {
+		list.evictor.remove(elem)
+	}
+	if list.expiry != nil {
+		list.expiry.cancel(elem)
+	}
+}
+
+// relinkElement links a detached elem again at its former position,
+// which is after the elements with equal key inserted before it.
+func (list *skipListUnSafe[K, V]) relinkElement(elem *Element[K, V]) {
+	prevs := list.getPrevElementNodes(elem.key)
+	ranks := list.prevRanksCache
+	for next := prevs[0].next[0]; next != nil && next.seq < elem.seq && list.comparable(next.key, elem.key) == 0; next = next.next[0] {
+		rank := ranks[0] + 1
+		for i := range next.next {
+			prevs[i] = next.elementHeader
+			ranks[i] = rank
+		}
+	}
+	list.linkElement(prevs, elem)
 }
 
 // RemoveFront removes front element node and returns the removed element.
@@ -358,7 +715,7 @@ func (list *skipListUnSafe[K, V]) RemoveBack() (back *Element[K, V]) {
 	if list.length == 0 {
 		return
 	}
-	back = list.back
+	back = list.Back()
 	list.RemoveElement(back)
 	return
 }
@@ -370,7 +727,90 @@ func (list *skipListUnSafe[K, V]) RemoveElement(elem *Element[K, V]) {
 	if elem == nil || elem.list != list {
 		return
 	}
-	_ = list.Remove(elem.key)
+	if prevs := list.prevsOf(elem); prevs != nil {
+		list.unlinkElement(prevs, elem)
+	}
+}
+
+// prevsOf returns the previous nodes of elem on each level.
+// Returns nil if elem is stale, e.g. it was in the list before Init.
+func (list *skipListUnSafe[K, V]) prevsOf(elem *Element[K, V]) (prevs []*elementHeader[K, V]) {
+	prevs = list.getPrevElementNodes(elem.key)
+	// step over preceding elements with equal key
+	next := prevs[0].next[0]
+	for ; next != nil && next != elem && list.comparable(next.key, elem.key) == 0; next = next.next[0] {
+		for i := range next.next {
+			prevs[i] = next.elementHeader
+		}
+	}
+	if next != elem {
+		return nil
+	}
+	return
+}
+
+// RemoveRange removes all elements with key between from and to.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// Returns the number of removed elements.
+//
+// The complexity is O(log(N)+M), M is the number of removed elements.
+func (list *skipListUnSafe[K, V]) RemoveRange(from, to K, options ...RangeOption) (removed int) {
+	option := newRangeOptions(options)
+	prevs := list.searchPrevElementNodes(from, option.exclusiveFrom)
+	elem := prevs[0].next[0]
+	if elem == nil {
+		return
+	}
+	before := elem.prev
+	for elem != nil {
+		c := list.comparable(elem.key, to)
+		if c > 0 || c == 0 && option.exclusiveTo {
+			break
+		}
+		for i := range elem.next {
+			prevs[i].span[i] += elem.span[i]
+			prevs[i].next[i] = elem.next[i]
+		}
+		next := elem.next[0]
+		list.preserve(elem)
+		list.tombstone(elem.key)
+		if list.evictor != nil {
+			list.evictor.remove(elem)
+		}
+		if list.expiry != nil {
+			list.expiry.cancel(elem)
+		}
+		list.pool.Put(elem)
+		elem = next
+		removed++
+	}
+	if removed == 0 {
+		return
+	}
+	for i := 0; i < list.maxLevel; i++ {
+		prevs[i].span[i] -= removed
+	}
+	if elem == nil {
+		list.back = before
+	} else {
+		elem.prev = before
+	}
+	list.length -= removed
+	return
+}
+
+// CountRange returns the number of elements with key between from and to.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// Expired elements are counted until they are reclaimed, see SetWithTTL.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) CountRange(from, to K, options ...RangeOption) (count int) {
+	option := newRangeOptions(options)
+	count = list.rankPrev(to, !option.exclusiveTo) - list.rankPrev(from, option.exclusiveFrom)
+	if count < 0 {
+		return 0
+	}
+	return
 }
 
 // MaxLevel returns current max level value.
@@ -386,15 +826,75 @@ func (list *skipListUnSafe[K, V]) Values() (values []V) {
 	return
 }
 
-// Index returns index of element
+// Index returns index of element.
+// If elem is nil or not in the list, returns -1.
+// Expired elements are counted until they are reclaimed, see SetWithTTL.
+//
+// The complexity is O(log(N)).
 func (list *skipListUnSafe[K, V]) Index(elem *Element[K, V]) (i int) {
-	if elem == nil {
+	if elem == nil || elem.list != list {
 		return -1
 	}
-	for e := elem.Prev(); e != nil; e = e.Prev() {
-		i++
+	var prev = &list.elementHeader
+	var rank int
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		next := prev.next[i]
+		for next != nil && list.comparable(elem.key, next.key) > 0 {
+			rank += prev.span[i]
+			prev = next.elementHeader
+			next = next.next[i]
+		}
+	}
+	// step over preceding elements with equal key
+	for next := prev.next[0]; next != nil && list.comparable(elem.key, next.key) == 0; next = next.next[0] {
+		if next == elem {
+			return rank
+		}
+		rank++
+	}
+	return -1
+}
+
+// GetByRank returns the element at index i.
+// A negative i counts from the back, so -1 is the last element.
+// If i is out of range, returns nil.
+// Expired elements are counted until they are reclaimed and may be returned, see SetWithTTL.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) GetByRank(i int) (elem *Element[K, V]) {
+	if i < 0 {
+		i += list.length
+	}
+	if i < 0 || i >= list.length {
+		return nil
 	}
-	return i
+	var prev = &list.elementHeader
+	var rank int
+	for l := list.maxLevel - 1; l >= 0; l-- {
+		for prev.next[l] != nil && rank+prev.span[l] <= i+1 {
+			rank += prev.span[l]
+			elem = prev.next[l]
+			if rank == i+1 {
+				return elem
+			}
+			prev = elem.elementHeader
+		}
+	}
+	return nil
+}
+
+// RemoveByRank removes the element at index i and returns the removed element.
+// A negative i counts from the back, so -1 is the last element.
+// If i is out of range, returns nil.
+//
+// The complexity is O(log(N)).
+func (list *skipListUnSafe[K, V]) RemoveByRank(i int) (elem *Element[K, V]) {
+	elem = list.GetByRank(i)
+	if elem == nil {
+		return nil
+	}
+	list.RemoveElement(elem)
+	return
 }
 
 // Keys returns list of keys
@@ -405,6 +905,79 @@ func (list *skipListUnSafe[K, V]) Keys() (keys []K) {
 	return
 }
 
+// All returns an iterator over key-value pairs from front to back.
+func (list *skipListUnSafe[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for el := list.Front(); el != nil; el = el.Next() {
+			if !yield(el.key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over key-value pairs from back to front.
+func (list *skipListUnSafe[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for el := list.Back(); el != nil; el = el.Prev() {
+			if !yield(el.key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Ascend returns an iterator over key-value pairs greater or equal to from, in ascending order.
+func (list *skipListUnSafe[K, V]) Ascend(from K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for el := list.Find(from); el != nil; el = el.Next() {
+			if !yield(el.key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Descend returns an iterator over key-value pairs less or equal to from, in descending order.
+func (list *skipListUnSafe[K, V]) Descend(from K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for el := list.Floor(from); el != nil; el = el.Prev() {
+			if !yield(el.key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// KeysSeq returns an iterator over keys from front to back.
+func (list *skipListUnSafe[K, V]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for el := list.Front(); el != nil; el = el.Next() {
+			if !yield(el.key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over values from front to back.
+func (list *skipListUnSafe[K, V]) ValuesSeq() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for el := list.Front(); el != nil; el = el.Next() {
+			if !yield(el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// View calls f with a read-only view of the list.
+// Elements reached in f, including through Next() and Prev(), are consistent during the call.
+// f must not modify the list.
+func (list *skipListUnSafe[K, V]) View(f func(view ReadView[K, V])) {
+	f(list)
+}
+
 // SetMaxLevel changes skip list max level.
 // If level is not greater than 0, just panic.
 func (list *skipListUnSafe[K, V]) SetMaxLevel(level int) (old int) {
@@ -414,6 +987,7 @@ func (list *skipListUnSafe[K, V]) SetMaxLevel(level int) (old int) {
 	}
 	for len(list.prevNodesCache) < level {
 		list.prevNodesCache = append(list.prevNodesCache, nil)
+		list.prevRanksCache = append(list.prevRanksCache, 0)
 	}
 	list.probTable = probabilityTable(DefaultProbability, level)
 	list.maxLevel = level
@@ -424,31 +998,143 @@ func (list *skipListUnSafe[K, V]) SetMaxLevel(level int) (old int) {
 	}
 
 	if old > level {
-		for i := old - 1; i >= level; i-- {
-			if list.next[i] != nil {
-				level = i
-				break
-			}
+		// cut every tower down to the new level, so spans of the remaining levels stay valid.
+		for elem := list.next[level]; elem != nil; {
+			next := elem.next[level]
+			elem.next = elem.next[:level]
+			elem.span = elem.span[:level]
+			elem = next
 		}
 		list.next = list.next[:level]
-		return
-	}
-
-	if level <= cap(list.next) {
-		list.next = list.next[:level]
+		list.span = list.span[:level]
 		return
 	}
 
 	levels := make([]*Element[K, V], level)
+	spans := make([]int, level)
 	copy(levels, list.next)
+	copy(spans, list.span)
+	for i := old; i < level; i++ {
+		spans[i] = list.length
+	}
 	list.next = levels
+	list.span = spans
 	return
 }
 
-func (list *skipListUnSafe[K, V]) randLevel() (level int) {
-	r := float64(list.rand.Int63()) / (1 << 63)
-	for level = 1; level < list.maxLevel && r < list.probTable[level]; level++ {
+// ErrNotSorted is returned when input that must be sorted is out of order.
+var ErrNotSorted = errors.New("skiplist: input is not sorted")
+
+// sortedBuilder links elements at the back of a new list in O(1) each.
+// It is used to build a list from sorted input in linear time.
+type sortedBuilder[K, V any] struct {
+	list   *skipListUnSafe[K, V]
+	header elementHeader[K, V]
+	back   *Element[K, V]
+	length int
+	tails  []*elementHeader[K, V]
+	ranks  []int
+}
+
+func (list *skipListUnSafe[K, V]) newSortedBuilder() *sortedBuilder[K, V] {
+	b := &sortedBuilder[K, V]{
+		list: list,
+		header: elementHeader[K, V]{
+			next: make([]*Element[K, V], list.maxLevel),
+			span: make([]int, list.maxLevel),
+		},
+		tails: make([]*elementHeader[K, V], list.maxLevel),
+		ranks: make([]int, list.maxLevel),
+	}
+	for i := range b.tails {
+		b.tails[i] = &b.header
+	}
+	return b
+}
 
+// append links a new element after the current back.
+// Returns ErrNotSorted if key would break the order of the list.
+func (b *sortedBuilder[K, V]) append(key K, value V) error {
+	list := b.list
+	if b.back != nil {
+		c := list.comparable(b.back.key, key)
+		if c > 0 || c == 0 && !list.duplicates {
+			return ErrNotSorted
+		}
+	}
+	elem := list.pool.Get(list, list.randLevel(), key, value)
+	list.stamp(elem)
+	b.length++
+	for i := range elem.next {
+		b.tails[i].next[i] = elem
+		b.tails[i].span[i] = b.length - b.ranks[i]
+		b.tails[i] = elem.elementHeader
+		b.ranks[i] = b.length
+	}
+	for i := len(elem.next); i < list.maxLevel; i++ {
+		b.tails[i].span[i]++
+	}
+	elem.prev = b.back
+	b.back = elem
+	return nil
+}
+
+// commit replaces all elements of the list with the built elements.
+func (b *sortedBuilder[K, V]) commit() {
+	b.list.preserveAll()
+	b.list.elementHeader = b.header
+	b.list.back = b.back
+	b.list.length = b.length
+	if b.list.tombstones != nil {
+		b.list.tombstones.clear()
+	}
+	if e := b.list.expiry; e != nil {
+		// deadlines of the replaced elements must not expire the built ones.
+		e.reset()
+		for elem := b.list.next[0]; elem != nil; elem = elem.next[0] {
+			if elem.expireAt != 0 {
+				e.schedule(elem)
+			}
+		}
+	}
+	if e := b.list.evictor; e != nil {
+		e.reset()
+		for elem := b.list.next[0]; elem != nil; elem = elem.next[0] {
+			e.add(elem)
+		}
+	}
+}
+
+// findPrev returns the last element that is less than key.
+// If inclusive is true, an element equal to key is also accepted.
+func (list *skipListUnSafe[K, V]) findPrev(key K, inclusive bool) (prev *Element[K, V]) {
+	header := &list.elementHeader
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		for next := header.next[i]; next != nil; next = next.next[i] {
+			c := list.comparable(next.key, key)
+			if c > 0 || c == 0 && !inclusive {
+				break
+			}
+			prev = next
+			header = next.elementHeader
+		}
+	}
+	return
+}
+
+// rankPrev returns the number of elements that are less than key.
+// If inclusive is true, elements equal to key are also counted.
+func (list *skipListUnSafe[K, V]) rankPrev(key K, inclusive bool) (rank int) {
+	header := &list.elementHeader
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		for next := header.next[i]; next != nil; next = next.next[i] {
+			c := list.comparable(next.key, key)
+			if c > 0 || c == 0 && !inclusive {
+				break
+			}
+			rank += header.span[i]
+			header = next.elementHeader
+		}
 	}
 	return
 }
@@ -458,16 +1144,63 @@ func (list *skipListUnSafe[K, V]) randLevel() (level int) {
 // caches them. This approach is similar to a "search finger" as described by Pugh:
 // http://citeseerx.ist.psu.edu/viewdoc/summary?doi=10.1.1.17.524
 // original by https://github.com/sean-public/fast-skiplist
+//
+// The rank of each previous node is cached in prevRanksCache as a side effect.
 func (list *skipListUnSafe[K, V]) getPrevElementNodes(key K) (prevs []*elementHeader[K, V]) {
+	return list.searchPrevElementNodes(key, false)
+}
+
+// getPrevElementNodesFrom works like getPrevElementNodes, but uses the cached previous nodes
+// of the last search as a search finger, so it's faster when key is close to the last key.
+// key must not be less than the key of the last search.
+func (list *skipListUnSafe[K, V]) getPrevElementNodesFrom(key K) (prevs []*elementHeader[K, V]) {
+	prevs = list.prevNodesCache
+	ranks := list.prevRanksCache
+	// climb until the cached previous node is still before key, it is then also valid on higher levels.
+	top := 0
+	for ; top < list.maxLevel; top++ {
+		next := prevs[top].next[top]
+		if next == nil || list.comparable(key, next.key) <= 0 {
+			break
+		}
+	}
+	prev := &list.elementHeader
+	rank := 0
+	for i := top - 1; i >= 0; i-- {
+		if ranks[i] > rank {
+			prev, rank = prevs[i], ranks[i]
+		}
+		next := prev.next[i]
+		for next != nil && list.comparable(key, next.key) > 0 {
+			rank += prev.span[i]
+			prev = next.elementHeader
+			next = next.next[i]
+		}
+		prevs[i] = prev
+		ranks[i] = rank
+	}
+	return
+}
+
+// searchPrevElementNodes works like getPrevElementNodes.
+// If inclusive is true, the previous nodes may also be equal to key.
+func (list *skipListUnSafe[K, V]) searchPrevElementNodes(key K, inclusive bool) (prevs []*elementHeader[K, V]) {
 	prev := &list.elementHeader
 	prevs = list.prevNodesCache
+	ranks := list.prevRanksCache
+	rank := 0
 	for i := list.maxLevel - 1; i >= 0; i-- {
 		next := prev.next[i]
-		for next != nil && list.comparable(key, next.key) > 0 {
+		for next != nil {
+			if c := list.comparable(key, next.key); c < 0 || c == 0 && !inclusive {
+				break
+			}
+			rank += prev.span[i]
 			prev = next.elementHeader
 			next = next.next[i]
 		}
 		prevs[i] = prev
+		ranks[i] = rank
 	}
 	return
 }
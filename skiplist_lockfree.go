@@ -0,0 +1,747 @@
+package skiplist
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+)
+
+// lockFreeSkipList is a SkipList backed by a LockFreeSkipList, see WithLockFree.
+type lockFreeSkipList[K, V any] struct {
+	core       *LockFreeSkipList[K, V]
+	header     elementHeader[K, V] // empty header of all elements of the list.
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+}
+
+var _ = SkipList[int, int](&lockFreeSkipList[int, int]{})
+
+func newLockFreeSkipList[K, V any](comparable Comparable[K], option *Options) *lockFreeSkipList[K, V] {
+	if option.duplicates || option.capacity != 0 || option.tombstones {
+		panic(fmt.Errorf("skiplist: WithLockFree can't be combined with WithDuplicates, WithCapacity or WithTombstones"))
+	}
+	list := &lockFreeSkipList[K, V]{core: newLockFree[K, V](comparable, option)}
+	list.keyCodec, list.valueCodec = codecsOf[K, V](option)
+	return list
+}
+
+// element returns a new element copied from node with value v, or nil if node is nil.
+func (list *lockFreeSkipList[K, V]) element(node *lockFreeNode[K, V], v *lockFreeValue[V]) *Element[K, V] {
+	if node == nil || v == nil {
+		return nil
+	}
+	return &Element[K, V]{
+		elementHeader: &list.header,
+		Value:         v.value,
+		key:           node.key,
+		seq:           node.seq,
+		list:          list,
+	}
+}
+
+func (list *lockFreeSkipList[K, V]) step(elem *Element[K, V], forward bool) *Element[K, V] {
+	s := list.core.state.Load()
+	if forward {
+		return list.element(list.core.ceiling(s, elem.key, true, false))
+	}
+	return list.element(list.core.last(s, &elem.key, false))
+}
+
+// node returns the node elem was copied from, or nil if it's no longer in the list.
+func (list *lockFreeSkipList[K, V]) node(s *lockFreeState[K, V], elem *Element[K, V]) *lockFreeNode[K, V] {
+	if elem == nil || elem.list != ReadView[K, V](list) {
+		return nil
+	}
+	node, _ := list.core.ceiling(s, elem.key, false, true)
+	if node == nil || node.seq != elem.seq {
+		return nil
+	}
+	return node
+}
+
+// walk calls f for each node which isn't removed from the first node greater or equal to from,
+// or greater than from if exclusive is true, until f returns false. Expired nodes are included.
+func (list *lockFreeSkipList[K, V]) walk(s *lockFreeState[K, V], from *K, exclusive bool, f func(node *lockFreeNode[K, V], v *lockFreeValue[V]) bool) {
+	node := s.head
+	if from != nil {
+		node = list.core.before(s, from, exclusive)
+	}
+	for node, v := list.core.after(node, true); node != nil; node, v = list.core.after(node, true) {
+		if !f(node, v) {
+			return
+		}
+	}
+}
+
+// inRange returns true if key is not past the upper bound to.
+func (list *lockFreeSkipList[K, V]) inRange(key, to K, exclusive bool) bool {
+	c := list.core.comparable(key, to)
+	return c < 0 || c == 0 && !exclusive
+}
+
+// Init resets the list and discards all existing elements.
+// Concurrent writers may still finish on the old elements, which are dropped with them.
+//
+// The complexity is O(1).
+func (list *lockFreeSkipList[K, V]) Init() SkipList[K, V] {
+	list.core.state.Store(list.core.newState())
+	return list
+}
+
+// SetProbability changes the current P value of the list.
+// It doesn't alter any existing data, only changes how future insert heights are calculated.
+//
+// The complexity is O(L), L is the max level.
+func (list *lockFreeSkipList[K, V]) SetProbability(newProbability float64) {
+	list.core.setLevels(newProbability, list.MaxLevel())
+}
+
+// SetMaxLevel changes the max level used by future inserts and returns the old one.
+// The probability is reset to DefaultProbability. If level is not greater than 0, just panic.
+//
+// The complexity is O(L), L is the new max level.
+func (list *lockFreeSkipList[K, V]) SetMaxLevel(level int) (old int) {
+	if level <= 0 {
+		panic(fmt.Errorf("skiplist: level must be larger than 0 (current is %v)", level))
+	}
+	old = list.MaxLevel()
+	list.core.setLevels(DefaultProbability, level)
+	return
+}
+
+// MaxLevel returns current max level value.
+//
+// The complexity is O(1).
+func (list *lockFreeSkipList[K, V]) MaxLevel() int {
+	return len(list.core.levels.Load().probTable)
+}
+
+// Len returns element count in this list.
+// Expired elements are counted until they are reclaimed, see SetWithTTL.
+//
+// The complexity is O(1).
+func (list *lockFreeSkipList[K, V]) Len() int {
+	return list.core.Len()
+}
+
+// Front returns a copy of the first element.
+//
+// The complexity is O(1).
+func (list *lockFreeSkipList[K, V]) Front() (front *Element[K, V]) {
+	return list.element(list.core.after(list.core.state.Load().head, false))
+}
+
+// Back returns a copy of the last element.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) Back() *Element[K, V] {
+	return list.element(list.core.last(list.core.state.Load(), nil, false))
+}
+
+// FindNext returns the first element after start that is greater or equal to key.
+// If start is greater or equal to key, returns start.
+// If start is nil, find element from head.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) FindNext(start *Element[K, V], key K) (next *Element[K, V]) {
+	if start != nil && list.core.comparable(key, start.key) <= 0 {
+		return start
+	}
+	return list.Find(key)
+}
+
+// Find returns the first element that is greater or equal to key.
+// It's short hand for FindNext(nil, key).
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) Find(key K) (elem *Element[K, V]) {
+	return list.element(list.core.ceiling(list.core.state.Load(), key, false, false))
+}
+
+// FindPrev returns the last element before start that is less or equal to key.
+// If start is less or equal to key, returns start.
+// If there is no such element, returns nil.
+// If start is nil, find element from back.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) FindPrev(start *Element[K, V], key K) (prev *Element[K, V]) {
+	if start != nil && list.core.comparable(start.key, key) <= 0 {
+		return start
+	}
+	return list.Floor(key)
+}
+
+// Floor returns the last element that is less or equal to key.
+// It's short hand for FindPrev(nil, key).
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) Floor(key K) (elem *Element[K, V]) {
+	return list.element(list.core.last(list.core.state.Load(), &key, true))
+}
+
+// Lower returns the last element that is less than key.
+// If there is no such element, returns nil.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) Lower(key K) (elem *Element[K, V]) {
+	return list.element(list.core.last(list.core.state.Load(), &key, false))
+}
+
+// Higher returns the first element that is greater than key.
+// If there is no such element, returns nil.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) Higher(key K) (elem *Element[K, V]) {
+	return list.element(list.core.ceiling(list.core.state.Load(), key, true, false))
+}
+
+// Range calls f sequentially for each element with key between from and to.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// If f returns false, range stops the iteration.
+// Nothing is locked, so f may modify the list, and the iteration is weakly consistent.
+//
+// The complexity is O(log(N)+M), M is the number of visited elements.
+func (list *lockFreeSkipList[K, V]) Range(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption) {
+	option := newRangeOptions(options)
+	s := list.core.state.Load()
+	for node, v := list.core.ceiling(s, from, option.exclusiveFrom, false); node != nil; node, v = list.core.after(node, false) {
+		if !list.inRange(node.key, to, option.exclusiveTo) || !f(list.element(node, v)) {
+			return
+		}
+	}
+}
+
+// ReverseRange calls f sequentially for each element with key between from and to in reverse order.
+// from is the upper bound and to is the lower bound.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// If f returns false, range stops the iteration.
+// Nothing is locked, so f may modify the list, and the iteration is weakly consistent.
+// Each step searches the list again, since nodes have no back links.
+//
+// The complexity is O((M+1)*log(N)), M is the number of visited elements.
+func (list *lockFreeSkipList[K, V]) ReverseRange(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption) {
+	option := newRangeOptions(options)
+	s := list.core.state.Load()
+	for node, v := list.core.last(s, &from, !option.exclusiveFrom); node != nil; node, v = list.core.last(s, &node.key, false) {
+		c := list.core.comparable(node.key, to)
+		if c < 0 || c == 0 && option.exclusiveTo || !f(list.element(node, v)) {
+			return
+		}
+	}
+}
+
+// Get returns a copy of the element with the key.
+// If the key is not found, returns nil.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) Get(key K) (elem *Element[K, V]) {
+	if elem = list.Find(key); elem != nil && list.core.comparable(elem.key, key) == 0 {
+		return elem
+	}
+	return nil
+}
+
+// GetAll returns the element with the key in a slice, since the list has no duplicates.
+// If the key is not found, returns nil.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) GetAll(key K) (elems []*Element[K, V]) {
+	if elem := list.Get(key); elem != nil {
+		elems = append(elems, elem)
+	}
+	return
+}
+
+// GetValue returns value of the element with the key.
+// It's short hand for Get().Value.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) GetValue(key K) (val V, ok bool) {
+	return list.core.GetValue(key)
+}
+
+// MustGetValue returns value of the element with the key.
+// It will panic if the key doesn't exist in the list.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) MustGetValue(key K) V {
+	val, ok := list.core.GetValue(key)
+	if !ok {
+		panic(fmt.Errorf("skiplist: cannot find key `%v` in skiplist", key))
+	}
+	return val
+}
+
+// CountRange returns the number of elements with key between from and to.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// Expired elements are counted until they are reclaimed, see SetWithTTL.
+// There are no spans, so the elements in range are walked.
+//
+// The complexity is O(log(N)+M), M is the number of counted elements.
+func (list *lockFreeSkipList[K, V]) CountRange(from, to K, options ...RangeOption) (count int) {
+	option := newRangeOptions(options)
+	list.walk(list.core.state.Load(), &from, option.exclusiveFrom, func(node *lockFreeNode[K, V], _ *lockFreeValue[V]) bool {
+		if !list.inRange(node.key, to, option.exclusiveTo) {
+			return false
+		}
+		count++
+		return true
+	})
+	return
+}
+
+// Index returns index of element, or -1 if it's not in the list.
+// Expired elements are counted until they are reclaimed, see SetWithTTL.
+// There are no spans, so the elements before elem are walked.
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) Index(elem *Element[K, V]) (i int) {
+	if elem == nil || elem.list != ReadView[K, V](list) {
+		return -1
+	}
+	i = -1
+	rank := 0
+	list.walk(list.core.state.Load(), nil, false, func(node *lockFreeNode[K, V], _ *lockFreeValue[V]) bool {
+		if c := list.core.comparable(node.key, elem.key); c >= 0 {
+			if c == 0 && node.seq == elem.seq {
+				i = rank
+			}
+			return false
+		}
+		rank++
+		return true
+	})
+	return
+}
+
+// GetByRank returns the element at index i.
+// A negative i counts from the back, so -1 is the last element.
+// If i is out of range, returns nil.
+// Expired elements are counted until they are reclaimed and may be returned, see SetWithTTL.
+// There are no spans, so the elements before index i are walked.
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) GetByRank(i int) (elem *Element[K, V]) {
+	node, v := list.byRank(list.core.state.Load(), i)
+	return list.element(node, v)
+}
+
+// byRank returns the node at index i, counting expired nodes, and its value.
+func (list *lockFreeSkipList[K, V]) byRank(s *lockFreeState[K, V], i int) (node *lockFreeNode[K, V], v *lockFreeValue[V]) {
+	if i < 0 {
+		i += int(s.length.Load())
+	}
+	if i < 0 {
+		return
+	}
+	rank := 0
+	list.walk(s, nil, false, func(n *lockFreeNode[K, V], nv *lockFreeValue[V]) bool {
+		if rank == i {
+			node, v = n, nv
+			return false
+		}
+		rank++
+		return true
+	})
+	return
+}
+
+// Keys returns list of keys
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) Keys() (keys []K) {
+	return list.core.Keys()
+}
+
+// Values returns list of values
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) Values() (values []V) {
+	return list.core.Values()
+}
+
+// All returns an iterator over key-value pairs from front to back.
+// The iteration is weakly consistent, and the loop body may modify the list.
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) All() iter.Seq2[K, V] {
+	return list.core.All()
+}
+
+// Backward returns an iterator over key-value pairs from back to front.
+// The iteration is weakly consistent, and the loop body may modify the list.
+// Each step searches the list again, since nodes have no back links.
+//
+// The complexity is O(N*log(N)).
+func (list *lockFreeSkipList[K, V]) Backward() iter.Seq2[K, V] {
+	return list.descend(nil)
+}
+
+// Ascend returns an iterator over key-value pairs greater or equal to from, in ascending order.
+// The iteration is weakly consistent, and the loop body may modify the list.
+//
+// The complexity is O(log(N)+M), M is the number of visited pairs.
+func (list *lockFreeSkipList[K, V]) Ascend(from K) iter.Seq2[K, V] {
+	return list.core.Ascend(from)
+}
+
+// Descend returns an iterator over key-value pairs less or equal to from, in descending order.
+// The iteration is weakly consistent, and the loop body may modify the list.
+// Each step searches the list again, since nodes have no back links.
+//
+// The complexity is O((M+1)*log(N)), M is the number of visited pairs.
+func (list *lockFreeSkipList[K, V]) Descend(from K) iter.Seq2[K, V] {
+	return list.descend(&from)
+}
+
+// descend returns an iterator from the last key less or equal to from, or from the back if from is nil.
+func (list *lockFreeSkipList[K, V]) descend(from *K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		s := list.core.state.Load()
+		for node, v := list.core.last(s, from, true); node != nil; node, v = list.core.last(s, &node.key, false) {
+			if !yield(node.key, v.value) {
+				return
+			}
+		}
+	}
+}
+
+// KeysSeq returns an iterator over keys from front to back.
+// The iteration is weakly consistent, and the loop body may modify the list.
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range list.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over values from front to back.
+// The iteration is weakly consistent, and the loop body may modify the list.
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) ValuesSeq() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range list.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Set sets value for the key.
+// If the key exists, replaces its value.
+// Returns a copy of the element holding the key and value.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) Set(key K, value V) (element *Element[K, V]) {
+	v := &lockFreeValue[V]{value: value}
+	node, _, updated := list.core.update(list.core.state.Load(), key, func(*lockFreeValue[V]) *lockFreeValue[V] { return v })
+	return list.element(node, updated)
+}
+
+// SetWithTTL sets value for the key like Set, and the element expires after ttl.
+// If ttl is not greater than 0, the element never expires.
+// Expired elements are reclaimed when a write reaches them, and by RemoveExpired.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) SetWithTTL(key K, value V, ttl time.Duration) (element *Element[K, V]) {
+	v := &lockFreeValue[V]{value: value}
+	if ttl > 0 {
+		v.expireAt = list.core.now() + int64(ttl)
+	}
+	node, _, updated := list.core.update(list.core.state.Load(), key, func(*lockFreeValue[V]) *lockFreeValue[V] { return v })
+	return list.element(node, updated)
+}
+
+// GetOrSet returns the existing element with the key.
+// Otherwise, it sets value for the key and returns the new element.
+// loaded is true if the element already existed.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) GetOrSet(key K, value V) (elem *Element[K, V], loaded bool) {
+	v := &lockFreeValue[V]{value: value}
+	node, old, updated := list.core.update(list.core.state.Load(), key, func(old *lockFreeValue[V]) *lockFreeValue[V] {
+		if old != nil {
+			return old
+		}
+		return v
+	})
+	return list.element(node, updated), old != nil
+}
+
+// Compute calls f with the current value of the key, exists is false if the key doesn't exist.
+// Depending on the returned action, the key is updated with the returned value, removed, or left unchanged.
+// Returns the element holding the key after Compute, or nil if there is none.
+// f is called again if the key is changed concurrently before the result is stored, so f must not have side effects.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) Compute(key K, f func(old V, exists bool) (value V, action ComputeAction)) (elem *Element[K, V]) {
+	node, _, v := list.core.update(list.core.state.Load(), key, func(old *lockFreeValue[V]) *lockFreeValue[V] {
+		var value V
+		if old != nil {
+			value = old.value
+		}
+		value, action := f(value, old != nil)
+		switch action {
+		case ComputeUpdate:
+			v := &lockFreeValue[V]{value: value}
+			if old != nil {
+				v.expireAt = old.expireAt
+			}
+			return v
+		case ComputeDelete:
+			return nil
+		}
+		return old
+	})
+	return list.element(node, v)
+}
+
+// CompareAndSwap sets new for the key if its current value is equal to old.
+// Values are compared with ==, so old must be of a comparable type, otherwise it panics.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	list.core.update(list.core.state.Load(), key, func(current *lockFreeValue[V]) *lockFreeValue[V] {
+		if swapped = current != nil && any(current.value) == any(old); !swapped {
+			return current
+		}
+		return &lockFreeValue[V]{value: new, expireAt: current.expireAt}
+	})
+	return
+}
+
+// CompareAndDelete removes the key if its current value is equal to old.
+// Values are compared with ==, so old must be of a comparable type, otherwise it panics.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	list.core.update(list.core.state.Load(), key, func(current *lockFreeValue[V]) *lockFreeValue[V] {
+		if deleted = current != nil && any(current.value) == any(old); !deleted {
+			return current
+		}
+		return nil
+	})
+	return
+}
+
+// Remove removes an element.
+// Returns a copy of the removed element if found, nil if it's not found.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) Remove(key K) (elem *Element[K, V]) {
+	node, old, _ := list.core.update(list.core.state.Load(), key, func(*lockFreeValue[V]) *lockFreeValue[V] { return nil })
+	return list.element(node, old)
+}
+
+// RemoveAll removes the element with the key, since the list has no duplicates.
+// Returns the number of removed elements.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) RemoveAll(key K) (removed int) {
+	if list.Remove(key) != nil {
+		removed = 1
+	}
+	return
+}
+
+// RemoveFront removes front element node and returns a copy of the removed element.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) RemoveFront() (front *Element[K, V]) {
+	s := list.core.state.Load()
+	for {
+		node, v := list.core.after(s.head, false)
+		if node == nil || list.core.remove(s, node, v) {
+			return list.element(node, v)
+		}
+	}
+}
+
+// RemoveBack removes back element node and returns a copy of the removed element.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) RemoveBack() (back *Element[K, V]) {
+	s := list.core.state.Load()
+	for {
+		node, v := list.core.last(s, nil, false)
+		if node == nil || list.core.remove(s, node, v) {
+			return list.element(node, v)
+		}
+	}
+}
+
+// RemoveElement removes the element elem was copied from, if it's still in the list.
+//
+// The complexity is O(log(N)).
+func (list *lockFreeSkipList[K, V]) RemoveElement(elem *Element[K, V]) {
+	s := list.core.state.Load()
+	node := list.node(s, elem)
+	if node == nil {
+		// elem is stale, e.g. it was in the list before Init.
+		return
+	}
+	for v := node.value.Load(); v != nil && !list.core.remove(s, node, v); v = node.value.Load() {
+	}
+}
+
+// RemoveRange removes all elements with key between from and to.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// Returns the number of removed elements.
+//
+// The complexity is O((M+1)*log(N)), M is the number of removed elements.
+func (list *lockFreeSkipList[K, V]) RemoveRange(from, to K, options ...RangeOption) (removed int) {
+	option := newRangeOptions(options)
+	s := list.core.state.Load()
+	list.walk(s, &from, option.exclusiveFrom, func(node *lockFreeNode[K, V], v *lockFreeValue[V]) bool {
+		if !list.inRange(node.key, to, option.exclusiveTo) {
+			return false
+		}
+		for ; v != nil; v = node.value.Load() {
+			if list.core.remove(s, node, v) {
+				removed++
+				break
+			}
+		}
+		return true
+	})
+	return
+}
+
+// RemoveByRank removes the element at index i and returns a copy of the removed element.
+// A negative i counts from the back, so -1 is the last element.
+// If i is out of range, returns nil.
+// There are no spans, so the elements before index i are walked.
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) RemoveByRank(i int) (elem *Element[K, V]) {
+	s := list.core.state.Load()
+	for {
+		node, v := list.byRank(s, i)
+		if node == nil || list.core.remove(s, node, v) {
+			return list.element(node, v)
+		}
+	}
+}
+
+// RemoveExpired reclaims all expired elements.
+// Returns the number of reclaimed elements.
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) RemoveExpired() (removed int) {
+	s := list.core.state.Load()
+	list.walk(s, nil, false, func(node *lockFreeNode[K, V], v *lockFreeValue[V]) bool {
+		if !list.core.alive(v) && list.core.remove(s, node, v) {
+			removed++
+			if list.core.onExpire != nil {
+				list.core.onExpire(node.key, v.value)
+			}
+		}
+		return true
+	})
+	return
+}
+
+// copy returns a list without lock holding the elements of the list.
+// Like iteration, the copy is weakly consistent.
+func (list *lockFreeSkipList[K, V]) copy() *skipListUnSafe[K, V] {
+	c := list.newUnsafe()
+	builder := c.newSortedBuilder()
+	for k, v := range list.All() {
+		// keys are ascending, so append never fails.
+		_ = builder.append(k, v)
+	}
+	builder.commit()
+	return c
+}
+
+// newUnsafe returns an empty list without lock, using the codecs of the list.
+func (list *lockFreeSkipList[K, V]) newUnsafe() *skipListUnSafe[K, V] {
+	c := newSkipListUnSafe[K, V](list.core.comparable, newOptions([]Option{WithMaxLevel(list.MaxLevel())}))
+	c.keyCodec, c.valueCodec = list.keyCodec, list.valueCodec
+	return c
+}
+
+// WriteTo writes the list to w using the codecs given by WithCodec.
+// The whole list is copied first, so the output is weakly consistent, like iteration.
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) WriteTo(w io.Writer) (n int64, err error) {
+	if list.keyCodec == nil || list.valueCodec == nil {
+		return 0, ErrNoCodec
+	}
+	return list.copy().WriteTo(w)
+}
+
+// Flush writes the list to w as a sorted table using the codecs given by WithCodec.
+// The whole list is copied first, so the table is weakly consistent, like iteration.
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) Flush(w io.Writer, options ...TableOption) (n int64, err error) {
+	if list.keyCodec == nil || list.valueCodec == nil {
+		return 0, ErrNoCodec
+	}
+	return list.copy().Flush(w, options...)
+}
+
+// ReadFrom replaces the list with the elements read from r using the codecs given by WithCodec.
+// The new elements become visible at once, and the list is unchanged if reading fails.
+//
+// The complexity is O(N), N is the number of read elements.
+func (list *lockFreeSkipList[K, V]) ReadFrom(r io.Reader) (n int64, err error) {
+	loaded := list.newUnsafe()
+	if n, err = loaded.ReadFrom(r); err != nil {
+		return
+	}
+	// build the new state before it's visible, nodes are appended at the back on each level.
+	s := list.core.newState()
+	tails := make([]*lockFreeNode[K, V], list.core.height)
+	for i := range tails {
+		tails[i] = s.head
+	}
+	levels := list.core.levels.Load()
+	for elem := loaded.next[0]; elem != nil; elem = elem.next[0] {
+		node := list.core.newNode(elem.key, levels.randLevel(), &lockFreeValue[V]{value: elem.Value})
+		node.seq = s.seq.Add(1)
+		s.raise(len(node.next))
+		for i := range node.next {
+			tails[i].next[i].Store(&lockFreeRef[K, V]{node: node})
+			tails[i] = node
+		}
+	}
+	s.length.Store(int64(loaded.length))
+	list.core.state.Store(s)
+	return
+}
+
+// View calls f with the list itself, since reads don't need a lock.
+// Walking elements with Next() and Prev() is weakly consistent.
+//
+// The complexity is O(1), plus the reads of f.
+func (list *lockFreeSkipList[K, V]) View(f func(view ReadView[K, V])) {
+	f(list)
+}
+
+// Snapshot returns a read-only copy of the whole list.
+// Like iteration, the copy is weakly consistent.
+//
+// The complexity is O(N).
+func (list *lockFreeSkipList[K, V]) Snapshot() ReadView[K, V] {
+	return list.copy()
+}
+
+// Apply returns an error wrapping errors.ErrUnsupported without applying batch,
+// since a batch can't be applied atomically without a lock.
+//
+// The complexity is O(1).
+func (list *lockFreeSkipList[K, V]) Apply(batch *Batch[K, V]) error {
+	return fmt.Errorf("skiplist: a lock-free list can't apply a batch atomically: %w", errors.ErrUnsupported)
+}
@@ -5,7 +5,9 @@ package skiplist
 
 import (
 	"github.com/stretchr/testify/assert"
+	"maps"
 	"math/rand"
+	"slices"
 	"testing"
 )
 
@@ -72,6 +74,343 @@ func TestIndex(t *testing.T) {
 	a.Equal(list.Get(2).Index(), 1)
 }
 
+func TestIndexSpans(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, int](NumberComparator[int])
+	for _, i := range rand.Perm(1000) {
+		list.Set(i, i)
+	}
+	assertSanity(a, list)
+	for _, i := range rand.Perm(1000)[:500] {
+		list.Remove(i)
+	}
+	assertSanity(a, list)
+	list.SetMaxLevel(4)
+	assertSanity(a, list)
+	list.SetMaxLevel(24)
+	for i := 1000; i < 1200; i++ {
+		list.Set(i, i)
+	}
+	assertSanity(a, list)
+	list.Init()
+	list.Set(1, 1)
+	assertSanity(a, list)
+}
+
+func TestRank(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, int](NumberComparator[int], WithMutex())
+	a.True(list.GetByRank(0) == nil)
+	a.True(list.RemoveByRank(-1) == nil)
+	for _, i := range rand.Perm(100) {
+		list.Set(i*10, i)
+	}
+	for i := 0; i < 100; i++ {
+		a.Equal(i*10, list.GetByRank(i).Key())
+		a.Equal((99-i)*10, list.GetByRank(-i-1).Key())
+	}
+	a.True(list.GetByRank(100) == nil)
+	a.True(list.GetByRank(-101) == nil)
+
+	a.Equal(500, list.RemoveByRank(50).Key())
+	a.Equal(990, list.RemoveByRank(-1).Key())
+	a.Equal(0, list.RemoveByRank(0).Key())
+	a.Equal(97, list.Len())
+	a.Equal(510, list.GetByRank(49).Key())
+	a.Equal(980, list.Back().Key())
+	assertSanity(a, list)
+}
+
+func TestRange(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, int](NumberComparator[int])
+	for i := 0; i < 10; i++ {
+		list.Set(i*10, i)
+	}
+	collect := func(from, to int, options ...RangeOption) (keys []int) {
+		list.Range(from, to, func(elem *Element[int, int]) bool {
+			keys = append(keys, elem.Key())
+			return true
+		}, options...)
+		return
+	}
+	a.Equal([]int{20, 30, 40}, collect(20, 40))
+	a.Equal([]int{30, 40}, collect(20, 40, WithExclusiveFrom()))
+	a.Equal([]int{20, 30}, collect(20, 40, WithExclusiveTo()))
+	a.Equal([]int{30}, collect(20, 40, WithExclusiveFrom(), WithExclusiveTo()))
+	a.Equal([]int{20, 30, 40}, collect(15, 45, WithExclusiveFrom(), WithExclusiveTo()))
+	a.Equal([]int{0, 10}, collect(-5, 10))
+	a.Equal([]int{80, 90}, collect(75, 100))
+	a.Nil(collect(20, 20, WithExclusiveTo()))
+	a.Nil(collect(40, 20))
+	a.Nil(collect(95, 200))
+
+	var keys []int
+	list.Range(0, 90, func(elem *Element[int, int]) bool {
+		keys = append(keys, elem.Key())
+		return len(keys) < 3
+	})
+	a.Equal([]int{0, 10, 20}, keys)
+}
+
+func TestFloorLowerHigher(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, int](NumberComparator[int])
+	a.True(list.Floor(0) == nil)
+	a.True(list.Higher(0) == nil)
+	for i := 0; i < 10; i++ {
+		list.Set(i*10, i)
+	}
+	a.Equal(20, list.Floor(20).Key())
+	a.Equal(20, list.Floor(25).Key())
+	a.True(list.Floor(-1) == nil)
+	a.Equal(90, list.Floor(1000).Key())
+	a.Equal(10, list.Lower(20).Key())
+	a.Equal(20, list.Lower(25).Key())
+	a.True(list.Lower(0) == nil)
+	a.Equal(30, list.Higher(20).Key())
+	a.Equal(30, list.Higher(25).Key())
+	a.Equal(0, list.Higher(-1).Key())
+	a.True(list.Higher(90) == nil)
+
+	a.Equal(20, list.FindPrev(nil, 25).Key())
+	a.Equal(list.Get(10), list.FindPrev(list.Get(10), 25))
+	a.Equal(20, list.FindPrev(list.Get(50), 25).Key())
+}
+
+func TestReverseRange(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, int](NumberComparator[int])
+	for i := 0; i < 10; i++ {
+		list.Set(i*10, i)
+	}
+	collect := func(from, to int, options ...RangeOption) (keys []int) {
+		list.ReverseRange(from, to, func(elem *Element[int, int]) bool {
+			keys = append(keys, elem.Key())
+			return true
+		}, options...)
+		return
+	}
+	a.Equal([]int{40, 30, 20}, collect(40, 20))
+	a.Equal([]int{30, 20}, collect(40, 20, WithExclusiveFrom()))
+	a.Equal([]int{40, 30}, collect(40, 20, WithExclusiveTo()))
+	a.Equal([]int{30}, collect(40, 20, WithExclusiveFrom(), WithExclusiveTo()))
+	a.Equal([]int{90, 80}, collect(100, 75))
+	a.Equal([]int{10, 0}, collect(10, -5))
+	a.Nil(collect(20, 40))
+	a.Nil(collect(-5, -10))
+}
+
+func TestIterators(t *testing.T) {
+	a := assert.New(t)
+	for _, list := range []SkipList[int, int]{
+		New[int, int](NumberComparator[int]),
+		New[int, int](NumberComparator[int], WithMutex()),
+	} {
+		for i := 4; i >= 0; i-- {
+			list.Set(i*10, i)
+		}
+		a.Equal([]int{0, 10, 20, 30, 40}, slices.Collect(list.KeysSeq()))
+		a.Equal([]int{0, 1, 2, 3, 4}, slices.Collect(list.ValuesSeq()))
+		a.Equal(map[int]int{0: 0, 10: 1, 20: 2, 30: 3, 40: 4}, maps.Collect(list.All()))
+
+		var keys []int
+		for k := range list.Backward() {
+			keys = append(keys, k)
+		}
+		a.Equal([]int{40, 30, 20, 10, 0}, keys)
+
+		keys = nil
+		for k, v := range list.Ascend(15) {
+			a.Equal(k/10, v)
+			keys = append(keys, k)
+			if len(keys) == 2 {
+				break
+			}
+		}
+		a.Equal([]int{20, 30}, keys)
+
+		keys = nil
+		for k := range list.Descend(30) {
+			keys = append(keys, k)
+		}
+		a.Equal([]int{30, 20, 10, 0}, keys)
+		a.Empty(slices.Collect(maps.Keys(maps.Collect(list.Ascend(41)))))
+	}
+}
+
+func TestRemoveRangeAndCountRange(t *testing.T) {
+	a := assert.New(t)
+	newList := func() SkipList[int, int] {
+		list := New[int, int](NumberComparator[int])
+		for _, i := range rand.Perm(100) {
+			list.Set(i*10, i)
+		}
+		return list
+	}
+	cases := []struct {
+		from, to int
+		options  []RangeOption
+		count    int
+		front    int
+		back     int
+	}{
+		{200, 400, nil, 21, 0, 990},
+		{200, 400, []RangeOption{WithExclusiveFrom()}, 20, 0, 990},
+		{200, 400, []RangeOption{WithExclusiveTo()}, 20, 0, 990},
+		{195, 405, []RangeOption{WithExclusiveFrom(), WithExclusiveTo()}, 21, 0, 990},
+		{-100, 100, nil, 11, 110, 990},
+		{900, 2000, nil, 10, 0, 890},
+		{-1, 2000, nil, 100, -1, -1},
+		{400, 200, nil, 0, 0, 990},
+		{401, 409, nil, 0, 0, 990},
+	}
+	for _, c := range cases {
+		list := newList()
+		a.Equal(c.count, list.CountRange(c.from, c.to, c.options...))
+		a.Equal(c.count, list.RemoveRange(c.from, c.to, c.options...))
+		a.Equal(100-c.count, list.Len())
+		a.Equal(0, list.CountRange(c.from, c.to, c.options...))
+		if c.front < 0 {
+			a.True(list.Front() == nil)
+			a.True(list.Back() == nil)
+		} else {
+			a.Equal(c.front, list.Front().Key())
+			a.Equal(c.back, list.Back().Key())
+		}
+		assertSanity(a, list)
+		var keys []int
+		for k := range list.Backward() {
+			keys = append(keys, k)
+		}
+		a.Equal(len(keys), list.Len())
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, int](NumberComparator[int], WithDuplicates())
+	for i := 0; i < 100; i++ {
+		list.Set(i%10, i)
+	}
+	a.Equal(100, list.Len())
+	assertSanity(a, list)
+	a.Equal(3, list.Get(3).Value)
+	a.Equal(3, list.Find(3).Value)
+	a.Equal(3, list.FindNext(nil, 3).Value)
+	a.Equal(93, list.Floor(3).Value)
+	var values []int
+	for _, elem := range list.GetAll(3) {
+		values = append(values, elem.Value)
+	}
+	a.Equal([]int{3, 13, 23, 33, 43, 53, 63, 73, 83, 93}, values)
+	a.Nil(list.GetAll(10))
+
+	for _, elem := range list.GetAll(5) {
+		a.Equal(elem.Value/10+50, list.Index(elem))
+	}
+	list.RemoveElement(list.GetAll(5)[4])
+	a.Equal(99, list.Len())
+	a.Equal(55, list.GetAll(5)[4].Value)
+	a.Equal(5, list.Remove(5).Value)
+	assertSanity(a, list)
+
+	a.Equal(10, list.RemoveAll(3))
+	a.Equal(0, list.RemoveAll(3))
+	a.Nil(list.GetAll(3))
+	a.Equal(88, list.Len())
+	assertSanity(a, list)
+}
+
+func TestRemoveStaleElement(t *testing.T) {
+	a := assert.New(t)
+	for _, options := range [][]Option{nil, {WithDuplicates()}} {
+		list := New[int, int](NumberComparator[int], options...)
+		old := list.Set(1, 1)
+		list.Init()
+		for i := 0; i < 5; i++ {
+			list.Set(i, i)
+		}
+		list.RemoveElement(old)
+		a.Equal(5, list.Len())
+		a.Equal([]int{0, 1, 2, 3, 4}, list.Keys())
+		assertSanity(a, list)
+	}
+}
+
+func TestFromSorted(t *testing.T) {
+	a := assert.New(t)
+	var keys, values []int
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, i*2)
+		values = append(values, i)
+	}
+	list, err := FromSorted(NumberComparator[int], keys, values, WithMutex())
+	a.NoError(err)
+	a.Equal(keys, list.Keys())
+	a.Equal(values, list.Values())
+	a.Equal(1000, list.Len())
+	a.Equal(1998, list.Back().Key())
+	assertSanity(a, list)
+	list.Set(5, 5)
+	list.Remove(10)
+	a.Equal(2, list.Index(list.Get(4)))
+	a.Equal(3, list.Index(list.Get(5)))
+	assertSanity(a, list)
+
+	list, err = FromSorted[int, int](NumberComparator[int], nil, nil)
+	a.NoError(err)
+	a.Equal(0, list.Len())
+
+	_, err = FromSorted(NumberComparator[int], []int{1, 3, 2}, []int{1, 2, 3})
+	a.ErrorIs(err, ErrNotSorted)
+	_, err = FromSorted(NumberComparator[int], []int{1, 1}, []int{1, 2})
+	a.ErrorIs(err, ErrNotSorted)
+	_, err = FromSorted(NumberComparator[int], []int{1}, []int{1, 2})
+	a.Error(err)
+
+	list, err = FromSorted(NumberComparator[int], []int{1, 1, 2}, []int{1, 2, 3}, WithDuplicates())
+	a.NoError(err)
+	a.Equal([]int{1, 2, 3}, list.Values())
+
+	list, err = FromSorted(NumberComparator[int], keys, values, WithLockFree())
+	a.NoError(err)
+	a.Equal(keys, list.Keys())
+	a.Equal(values, list.Values())
+	_, err = FromSorted(NumberComparator[int], []int{1, 3, 2}, []int{1, 2, 3}, WithLockFree())
+	a.ErrorIs(err, ErrNotSorted)
+}
+
+// assertSanity checks that every element's index matches its position and that
+// every span matches the distance to the next element on its level.
+func assertSanity[K, V any](a *assert.Assertions, list SkipList[K, V]) {
+	sl, ok := unsafeListOf(list)
+	if !a.True(ok) {
+		return
+	}
+	ranks := map[*elementHeader[K, V]]int{&sl.elementHeader: 0}
+	i := 0
+	for e := sl.Front(); e != nil; e = e.Next() {
+		a.Equal(i, sl.Index(e))
+		i++
+		ranks[e.elementHeader] = i
+	}
+	a.Equal(sl.Len(), i)
+	check := func(h *elementHeader[K, V]) {
+		for l, next := range h.next {
+			if next == nil {
+				a.Equal(sl.Len()-ranks[h], h.span[l])
+			} else {
+				a.Equal(ranks[next.elementHeader]-ranks[h], h.span[l])
+			}
+		}
+	}
+	check(&sl.elementHeader)
+	for e := sl.Front(); e != nil; e = e.Next() {
+		check(e.elementHeader)
+	}
+}
+
 func TestSkipList_FindNext(t *testing.T) {
 	a := assert.New(t)
 	list := New[float64, any](NumberComparator[float64])
@@ -104,7 +443,7 @@ func TestBasicCRUD(t *testing.T) {
 	a.Equal(list.Find(12.34), elem1)
 	a.True(list.Find(15) == nil)
 
-	//assertSanity(a, list)
+	assertSanity(a, list)
 	//
 	elem2 := list.Set(23.45, "second")
 	a.True(elem2 != nil)
@@ -118,7 +457,7 @@ func TestBasicCRUD(t *testing.T) {
 	a.Equal(list.Find(15), elem2)
 	a.True(list.Find(25) == nil)
 
-	//assertSanity(a, list)
+	assertSanity(a, list)
 	//
 	elem3 := list.Set(16.78, "middle")
 	a.True(elem3 != nil)
@@ -133,7 +472,7 @@ func TestBasicCRUD(t *testing.T) {
 	a.Equal(list.Find(15), elem3)
 	a.Equal(list.Find(20), elem2)
 
-	//assertSanity(a, list)
+	assertSanity(a, list)
 	//
 	elem4 := list.Set(9.01, "very beginning")
 	a.True(elem4 != nil)
@@ -149,7 +488,7 @@ func TestBasicCRUD(t *testing.T) {
 	a.Equal(list.Find(15), elem3)
 	a.Equal(list.Find(20), elem2)
 	//
-	////assertSanity(a, list)
+	assertSanity(a, list)
 	//
 	elem5 := list.Set(16.78, "middle overwrite")
 	a.True(elem3 != nil)
@@ -2,8 +2,12 @@
 package skiplist
 
 import (
+	"fmt"
+	"io"
+	"iter"
 	"math/rand"
 	"sync"
+	"time"
 )
 
 // SafeSkipList is the header of a skip list.
@@ -57,6 +61,7 @@ func (list *safeSkipList[K, V]) Back() *Element[K, V] {
 }
 
 // Len returns element count in this list.
+// Expired elements are counted until they are reclaimed, see SetWithTTL.
 //
 // The complexity is O(1).
 func (list *safeSkipList[K, V]) Len() int {
@@ -67,7 +72,7 @@ func (list *safeSkipList[K, V]) Len() int {
 
 // Set sets value for the key.
 // If the key exists, updates element's value.
-// Returns the element holding the key and value.
+// Returns the element holding the key and value, or nil if the new element is evicted right away, see WithCapacity.
 //
 // The complexity is O(log(N)).
 func (list *safeSkipList[K, V]) Set(key K, value V) (elem *Element[K, V]) {
@@ -92,14 +97,96 @@ func (list *safeSkipList[K, V]) Find(key K) (elem *Element[K, V]) {
 	return list.skipListUnSafe.Find(key)
 }
 
+// FindPrev returns the last element before start that is less or equal to key.
+// If start is less or equal to key, returns start.
+// If there is no such element, returns nil.
+// If start is nil, find element from back.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) FindPrev(start *Element[K, V], key K) (prev *Element[K, V]) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return list.skipListUnSafe.FindPrev(start, key)
+}
+
+// Floor returns the last element that is less or equal to key.
+// It's short hand for FindPrev(nil, key).
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) Floor(key K) (elem *Element[K, V]) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return list.skipListUnSafe.Floor(key)
+}
+
+// Lower returns the last element that is less than key.
+// If there is no such element, returns nil.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) Lower(key K) (elem *Element[K, V]) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return list.skipListUnSafe.Lower(key)
+}
+
+// Higher returns the first element that is greater than key.
+// If there is no such element, returns nil.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) Higher(key K) (elem *Element[K, V]) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return list.skipListUnSafe.Higher(key)
+}
+
+// Range calls f sequentially for each element with key between from and to.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// If f returns false, range stops the iteration.
+// The read lock is held during the whole iteration, so f must not modify the list.
+//
+// The complexity is O(log(N)+M), M is the number of visited elements.
+func (list *safeSkipList[K, V]) Range(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	list.skipListUnSafe.Range(from, to, f, options...)
+}
+
+// ReverseRange calls f sequentially for each element with key between from and to in reverse order.
+// from is the upper bound and to is the lower bound, iteration walks Prev() from the upper bound.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// If f returns false, range stops the iteration.
+// The read lock is held during the whole iteration, so f must not modify the list.
+//
+// The complexity is O(log(N)+M), M is the number of visited elements.
+func (list *safeSkipList[K, V]) ReverseRange(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	list.skipListUnSafe.ReverseRange(from, to, f, options...)
+}
+
 // Get returns an element with the key.
 // If the key is not found, returns nil.
 //
 // The complexity is O(log(N)).
 func (list *safeSkipList[K, V]) Get(key K) (elem *Element[K, V]) {
+	list.lock.RLock()
+	elem, expired := list.skipListUnSafe.get(key)
+	list.skipListUnSafe.touch(elem)
+	list.lock.RUnlock()
+	if expired {
+		list.reclaim(key)
+	}
+	return
+}
+
+// GetAll returns all elements with the key in insertion order.
+// If the key is not found, returns nil.
+//
+// The complexity is O(log(N)+M), M is the number of elements with the key.
+func (list *safeSkipList[K, V]) GetAll(key K) (elems []*Element[K, V]) {
 	list.lock.RLock()
 	defer list.lock.RUnlock()
-	return list.skipListUnSafe.Get(key)
+	return list.skipListUnSafe.GetAll(key)
 }
 
 // GetValue returns value of the element with the key.
@@ -108,8 +195,16 @@ func (list *safeSkipList[K, V]) Get(key K) (elem *Element[K, V]) {
 // The complexity is O(log(N)).
 func (list *safeSkipList[K, V]) GetValue(key K) (val V, ok bool) {
 	list.lock.RLock()
-	defer list.lock.RUnlock()
-	return list.skipListUnSafe.GetValue(key)
+	elem, expired := list.skipListUnSafe.get(key)
+	if elem != nil {
+		val, ok = elem.Value, true
+	}
+	list.skipListUnSafe.touch(elem)
+	list.lock.RUnlock()
+	if expired {
+		list.reclaim(key)
+	}
+	return
 }
 
 // MustGetValue returns value of the element with the key.
@@ -117,9 +212,18 @@ func (list *safeSkipList[K, V]) GetValue(key K) (val V, ok bool) {
 //
 // The complexity is O(log(N)).
 func (list *safeSkipList[K, V]) MustGetValue(key K) V {
-	list.lock.RLock()
-	defer list.lock.RUnlock()
-	return list.skipListUnSafe.MustGetValue(key)
+	val, ok := list.GetValue(key)
+	if !ok {
+		panic(fmt.Errorf("skiplist: cannot find key `%v` in skiplist", key))
+	}
+	return val
+}
+
+// reclaim removes expired elements with the key, which were found under the read lock.
+func (list *safeSkipList[K, V]) reclaim(key K) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	list.skipListUnSafe.reclaim(key)
 }
 
 // Remove removes an element.
@@ -132,6 +236,60 @@ func (list *safeSkipList[K, V]) Remove(key K) (elem *Element[K, V]) {
 	return list.skipListUnSafe.Remove(key)
 }
 
+// GetOrSet returns the existing element with the key.
+// Otherwise, it sets value for the key and returns the new element, or nil if it's evicted right away, see WithCapacity.
+// loaded is true if the element already existed.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) GetOrSet(key K, value V) (elem *Element[K, V], loaded bool) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.GetOrSet(key, value)
+}
+
+// Compute calls f with the current value of the key, exists is false if the key doesn't exist.
+// Depending on the returned action, the key is updated with the returned value, removed, or left unchanged.
+// If the list allows duplicates, only the first element with the key is used.
+// Returns the element holding the key after Compute, or nil if there is none.
+// The lock is held while f runs, so f must not use the list.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) Compute(key K, f func(old V, exists bool) (value V, action ComputeAction)) (elem *Element[K, V]) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.Compute(key, f)
+}
+
+// CompareAndSwap sets new for the key if its current value is equal to old.
+// Values are compared with ==, so old must be of a comparable type, otherwise it panics.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.CompareAndSwap(key, old, new)
+}
+
+// CompareAndDelete removes the key if its current value is equal to old.
+// Values are compared with ==, so old must be of a comparable type, otherwise it panics.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.CompareAndDelete(key, old)
+}
+
+// RemoveAll removes all elements with the key.
+// Returns the number of removed elements.
+//
+// The complexity is O(log(N)+M), M is the number of removed elements.
+func (list *safeSkipList[K, V]) RemoveAll(key K) (removed int) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.RemoveAll(key)
+}
+
 // RemoveFront removes front element node and returns the removed element.
 //
 // The complexity is O(1).
@@ -159,6 +317,28 @@ func (list *safeSkipList[K, V]) RemoveElement(elem *Element[K, V]) {
 	list.skipListUnSafe.RemoveElement(elem)
 }
 
+// RemoveRange removes all elements with key between from and to.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// Returns the number of removed elements.
+//
+// The complexity is O(log(N)+M), M is the number of removed elements.
+func (list *safeSkipList[K, V]) RemoveRange(from, to K, options ...RangeOption) (removed int) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.RemoveRange(from, to, options...)
+}
+
+// CountRange returns the number of elements with key between from and to.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// Expired elements are counted until they are reclaimed, see SetWithTTL.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) CountRange(from, to K, options ...RangeOption) (count int) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return list.skipListUnSafe.CountRange(from, to, options...)
+}
+
 // MaxLevel returns current max level value.
 func (list *safeSkipList[K, V]) MaxLevel() int {
 	list.lock.RLock()
@@ -166,13 +346,62 @@ func (list *safeSkipList[K, V]) MaxLevel() int {
 	return list.maxLevel
 }
 
-// Index returns index of element
+// Index returns index of element.
+// Expired elements are counted until they are reclaimed, see SetWithTTL.
 func (list *safeSkipList[K, V]) Index(elem *Element[K, V]) (i int) {
 	list.lock.RLock()
 	defer list.lock.RUnlock()
 	return list.skipListUnSafe.Index(elem)
 }
 
+// GetByRank returns the element at index i.
+// A negative i counts from the back, so -1 is the last element.
+// If i is out of range, returns nil.
+// Expired elements are counted until they are reclaimed and may be returned, see SetWithTTL.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) GetByRank(i int) (elem *Element[K, V]) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return list.skipListUnSafe.GetByRank(i)
+}
+
+// SetWithTTL sets value for the key like Set, and the element expires after ttl.
+// If ttl is not greater than 0, the element never expires.
+//
+// Expired elements are invisible to Get, Find, Front, Back, Next, Prev and iteration,
+// but Len, Index, GetByRank and CountRange count them until they are reclaimed.
+// They are reclaimed when Get or a write reaches them, and by RemoveExpired.
+// See WithExpiry to set the clock and a callback for expired elements, and StartSweeper to reclaim them periodically.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) SetWithTTL(key K, value V, ttl time.Duration) (elem *Element[K, V]) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.SetWithTTL(key, value, ttl)
+}
+
+// RemoveExpired reclaims all expired elements.
+// Returns the number of reclaimed elements.
+//
+// The complexity is O(M*log(N)), M is the number of expired elements.
+func (list *safeSkipList[K, V]) RemoveExpired() (removed int) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.RemoveExpired()
+}
+
+// RemoveByRank removes the element at index i and returns the removed element.
+// A negative i counts from the back, so -1 is the last element.
+// If i is out of range, returns nil.
+//
+// The complexity is O(log(N)).
+func (list *safeSkipList[K, V]) RemoveByRank(i int) (elem *Element[K, V]) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.RemoveByRank(i)
+}
+
 // Values returns list of values
 func (list *safeSkipList[K, V]) Values() (values []V) {
 	list.lock.RLock()
@@ -187,11 +416,132 @@ func (list *safeSkipList[K, V]) Keys() (keys []K) {
 	return list.skipListUnSafe.Keys()
 }
 
+// All returns an iterator over key-value pairs from front to back.
+// The read lock is held during the whole iteration, so the loop body must not modify the list.
+func (list *safeSkipList[K, V]) All() iter.Seq2[K, V] {
+	return list.locked2(list.skipListUnSafe.All())
+}
+
+// Backward returns an iterator over key-value pairs from back to front.
+// The read lock is held during the whole iteration, so the loop body must not modify the list.
+func (list *safeSkipList[K, V]) Backward() iter.Seq2[K, V] {
+	return list.locked2(list.skipListUnSafe.Backward())
+}
+
+// Ascend returns an iterator over key-value pairs greater or equal to from, in ascending order.
+// The read lock is held during the whole iteration, so the loop body must not modify the list.
+func (list *safeSkipList[K, V]) Ascend(from K) iter.Seq2[K, V] {
+	return list.locked2(list.skipListUnSafe.Ascend(from))
+}
+
+// Descend returns an iterator over key-value pairs less or equal to from, in descending order.
+// The read lock is held during the whole iteration, so the loop body must not modify the list.
+func (list *safeSkipList[K, V]) Descend(from K) iter.Seq2[K, V] {
+	return list.locked2(list.skipListUnSafe.Descend(from))
+}
+
+// KeysSeq returns an iterator over keys from front to back.
+// The read lock is held during the whole iteration, so the loop body must not modify the list.
+func (list *safeSkipList[K, V]) KeysSeq() iter.Seq[K] {
+	seq := list.skipListUnSafe.KeysSeq()
+	return func(yield func(K) bool) {
+		list.lock.RLock()
+		defer list.lock.RUnlock()
+		seq(yield)
+	}
+}
+
+// ValuesSeq returns an iterator over values from front to back.
+// The read lock is held during the whole iteration, so the loop body must not modify the list.
+func (list *safeSkipList[K, V]) ValuesSeq() iter.Seq[V] {
+	seq := list.skipListUnSafe.ValuesSeq()
+	return func(yield func(V) bool) {
+		list.lock.RLock()
+		defer list.lock.RUnlock()
+		seq(yield)
+	}
+}
+
+// locked2 wraps seq to hold the read lock while it runs.
+func (list *safeSkipList[K, V]) locked2(seq iter.Seq2[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		list.lock.RLock()
+		defer list.lock.RUnlock()
+		seq(yield)
+	}
+}
+
+// View calls f with a read-only view of the list.
+// The read lock is held during the whole call, so walking elements with Next() and Prev()
+// doesn't race with writers. f must not modify the list.
+func (list *safeSkipList[K, V]) View(f func(view ReadView[K, V])) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	f(list.skipListUnSafe)
+}
+
+// Snapshot returns a read-only view of the list at this point in time.
+// Later writes to the list don't affect the view. Elements of the view are copies,
+// changing their Value doesn't change the list, and Value of elements changed directly in the list is not tracked.
+//
+// Taking a snapshot holds the lock for O(1). The view takes the read lock for each lookup,
+// and iterations release it every few hundred elements, so a long scan doesn't stall writers.
+// Lookups and iterations have the same complexities as on a list without WithMutex.
+func (list *safeSkipList[K, V]) Snapshot() ReadView[K, V] {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.snapshot(&list.lock)
+}
+
+// Apply applies all operations of batch in order while holding the lock once.
+// If the validator of batch rejects an operation, all applied operations are rolled back
+// and the error is returned. The validator must not use the list.
+// Operations sorted by key reuse the previous search as a finger, so sorted batches are faster.
+//
+// The complexity is O(M*log(N)), M is the number of operations.
+func (list *safeSkipList[K, V]) Apply(batch *Batch[K, V]) error {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.Apply(batch)
+}
+
 // SetMaxLevel changes skip list max level.
 // If level is not greater than 0, just panic.
 func (list *safeSkipList[K, V]) SetMaxLevel(level int) (old int) {
 	list.lock.Lock()
 	defer list.lock.Unlock()
-	list.skipListUnSafe.SetMaxLevel(level)
-	return
+	return list.skipListUnSafe.SetMaxLevel(level)
+}
+
+// WriteTo writes a binary snapshot of the list to w using the codecs given by WithCodec.
+// It implements io.WriterTo.
+//
+// The complexity is O(N).
+func (list *safeSkipList[K, V]) WriteTo(w io.Writer) (n int64, err error) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return list.skipListUnSafe.WriteTo(w)
+}
+
+// Flush writes the list to w as a sorted table using the codecs given by WithCodec.
+// A list created WithTombstones also writes tombstones of removed keys.
+// Use OpenTable to read the table.
+//
+// The complexity is O(N+T), T is the number of tombstones.
+func (list *safeSkipList[K, V]) Flush(w io.Writer, options ...TableOption) (n int64, err error) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return list.skipListUnSafe.Flush(w, options...)
+}
+
+// ReadFrom replaces all elements of the list with a snapshot read from r using the codecs given by WithCodec.
+// The list is left unchanged if the snapshot can't be read.
+// ReadFrom may read past the end of the snapshot from r.
+// It implements io.ReaderFrom.
+//
+// The complexity is O(N).
+func (list *safeSkipList[K, V]) ReadFrom(r io.Reader) (n int64, err error) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return list.skipListUnSafe.ReadFrom(r)
 }
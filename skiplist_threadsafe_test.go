@@ -1,6 +1,7 @@
 package skiplist
 
 import (
+	"math/rand"
 	"sync"
 	"testing"
 )
@@ -18,3 +19,125 @@ func TestSafeSkipList_Set(t *testing.T) {
 	wg.Wait()
 	//fmt.Println(list.Keys())
 }
+
+func TestSafeSkipList_Range(t *testing.T) {
+	list := New[int, int](NumberComparator[int], WithMutex())
+	wg := sync.WaitGroup{}
+	wg.Add(200)
+	for i := 0; i < 100; i++ {
+		go func(i int) {
+			list.Set(i, i)
+			wg.Done()
+		}(i)
+		go func() {
+			prev := -1
+			list.Range(10, 90, func(elem *Element[int, int]) bool {
+				if elem.Key() <= prev {
+					t.Errorf("range out of order: %v after %v", elem.Key(), prev)
+				}
+				prev = elem.Key()
+				return true
+			})
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSafeSkipList_View(t *testing.T) {
+	list := New[int, int](NumberComparator[int], WithMutex())
+	for i := 0; i < 100; i++ {
+		list.Set(i, i)
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			list.Set(rand.Intn(200), i)
+			list.Remove(rand.Intn(200))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			list.View(func(view ReadView[int, int]) {
+				count := 0
+				for elem := view.Front(); elem != nil; elem = elem.Next() {
+					_ = elem.Value
+					count++
+				}
+				if count != view.Len() {
+					t.Errorf("iterated %v elements, expected %v", count, view.Len())
+				}
+				for elem := view.Back(); elem != nil; elem = elem.Prev() {
+					_ = elem.Key()
+				}
+			})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			for k, v := range list.All() {
+				_, _ = k, v
+			}
+			list.View(func(view ReadView[int, int]) {
+				if elem := view.GetByRank(0); elem != nil {
+					_ = elem.Next()
+				}
+			})
+		}
+	}()
+	wg.Wait()
+}
+
+func TestSafeSkipList_Snapshot(t *testing.T) {
+	list := New[int, int](NumberComparator[int], WithMutex())
+	for i := 0; i < 1000; i++ {
+		list.Set(i, i)
+	}
+	snapshot := list.Snapshot()
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			list.Set(i, -i)
+			list.Remove(rand.Intn(1000))
+			list.Set(1000+i, i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 10; n++ {
+			i := 0
+			for elem := snapshot.Front(); elem != nil; elem = elem.Next() {
+				if elem.Key() != i || elem.Value != i {
+					t.Errorf("snapshot changed at %v: %v=%v", i, elem.Key(), elem.Value)
+				}
+				i++
+			}
+			if i != 1000 || snapshot.Len() != 1000 {
+				t.Errorf("snapshot length changed: %v", i)
+			}
+		}
+	}()
+	wg.Wait()
+	if v, _ := snapshot.GetValue(999); v != 999 {
+		t.Errorf("snapshot value changed: %v", v)
+	}
+	if snapshot.Index(snapshot.Get(500)) != 500 {
+		t.Errorf("snapshot index is broken")
+	}
+}
+
+func TestSafeSkipList_SetMaxLevel(t *testing.T) {
+	list := New[int, int](NumberComparator[int], WithMutex(), WithMaxLevel(8))
+	if old := list.SetMaxLevel(16); old != 8 {
+		t.Errorf("old max level is %v", old)
+	}
+	if old := list.SetMaxLevel(4); old != 16 || list.MaxLevel() != 4 {
+		t.Errorf("old max level is %v, max level is %v", old, list.MaxLevel())
+	}
+}
@@ -0,0 +1,197 @@
+package skiplist
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"iter"
+)
+
+// Snapshot format:
+//
+//	magic   [4]byte  "SKPL"
+//	version byte
+//	count   uvarint
+//	records count * (uvarint key length, key, uvarint value length, value)
+//	crc32   uint32   big-endian IEEE checksum of all preceding bytes
+const snapshotVersion = 1
+
+// maxSnapshotRecord limits the size of a single key or value to guard against corrupted lengths.
+const maxSnapshotRecord = 1 << 30
+
+var snapshotMagic = [4]byte{'S', 'K', 'P', 'L'}
+
+var (
+	// ErrNoCodec is returned by WriteTo and ReadFrom if the list has no codec. See WithCodec.
+	ErrNoCodec = errors.New("skiplist: no codec, see WithCodec")
+	// ErrInvalidSnapshot is returned by ReadFrom if the input is not a snapshot.
+	ErrInvalidSnapshot = errors.New("skiplist: invalid snapshot")
+	// ErrUnsupportedVersion is returned by ReadFrom if the snapshot version is unknown.
+	ErrUnsupportedVersion = errors.New("skiplist: unsupported snapshot version")
+	// ErrChecksumMismatch is returned by ReadFrom if the snapshot is corrupted.
+	ErrChecksumMismatch = errors.New("skiplist: snapshot checksum mismatch")
+)
+
+// WriteTo writes a binary snapshot of the list to w using the codecs given by WithCodec.
+// It implements io.WriterTo.
+//
+// The complexity is O(N).
+func (list *skipListUnSafe[K, V]) WriteTo(w io.Writer) (n int64, err error) {
+	count, elems := list.liveElements()
+	return list.writeSnapshot(w, count, elems)
+}
+
+// writeSnapshot writes count key-value pairs of elems as a binary snapshot to w.
+func (list *skipListUnSafe[K, V]) writeSnapshot(w io.Writer, count int, elems iter.Seq2[K, V]) (n int64, err error) {
+	if list.keyCodec == nil || list.valueCodec == nil {
+		return 0, ErrNoCodec
+	}
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+	buf := append([]byte{}, snapshotMagic[:]...)
+	buf = append(buf, snapshotVersion)
+	buf = binary.AppendUvarint(buf, uint64(count))
+	var data []byte
+	for key, value := range elems {
+		if data, err = list.keyCodec.Encode(data[:0], key); err != nil {
+			return
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(data)))
+		buf = append(buf, data...)
+		if data, err = list.valueCodec.Encode(data[:0], value); err != nil {
+			return
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(data)))
+		buf = append(buf, data...)
+		if len(buf) >= bw.Size() {
+			if err = writeCounted(bw, buf, &n); err != nil {
+				return
+			}
+			buf = buf[:0]
+		}
+	}
+	if err = writeCounted(bw, buf, &n); err != nil {
+		return
+	}
+	if err = bw.Flush(); err != nil {
+		return
+	}
+	err = writeCounted(w, binary.BigEndian.AppendUint32(nil, crc.Sum32()), &n)
+	return
+}
+
+// ReadFrom replaces all elements of the list with a snapshot read from r using the codecs given by WithCodec.
+// The list is left unchanged if the snapshot can't be read.
+// ReadFrom may read past the end of the snapshot from r.
+// It implements io.ReaderFrom.
+//
+// The complexity is O(N).
+func (list *skipListUnSafe[K, V]) ReadFrom(r io.Reader) (n int64, err error) {
+	if list.keyCodec == nil || list.valueCodec == nil {
+		return 0, ErrNoCodec
+	}
+	sr := &snapshotReader{r: bufio.NewReader(r), crc: crc32.NewIEEE()}
+	defer func() {
+		n = sr.n
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrInvalidSnapshot
+		}
+	}()
+	var header [len(snapshotMagic) + 1]byte
+	if _, err = io.ReadFull(sr, header[:]); err != nil {
+		return
+	}
+	if [4]byte(header[:4]) != snapshotMagic {
+		return 0, ErrInvalidSnapshot
+	}
+	if header[4] != snapshotVersion {
+		return 0, ErrUnsupportedVersion
+	}
+	count, err := binary.ReadUvarint(sr)
+	if err != nil {
+		return
+	}
+	builder := list.newSortedBuilder()
+	var data []byte
+	for i := uint64(0); i < count; i++ {
+		var key K
+		var value V
+		if data, err = sr.readRecord(data); err != nil {
+			return
+		}
+		if key, err = list.keyCodec.Decode(data); err != nil {
+			return
+		}
+		if data, err = sr.readRecord(data); err != nil {
+			return
+		}
+		if value, err = list.valueCodec.Decode(data); err != nil {
+			return
+		}
+		if err = builder.append(key, value); err != nil {
+			return
+		}
+	}
+	sum := sr.crc.Sum32()
+	var trailer [4]byte
+	if _, err = io.ReadFull(sr, trailer[:]); err != nil {
+		return
+	}
+	if binary.BigEndian.Uint32(trailer[:]) != sum {
+		return 0, ErrChecksumMismatch
+	}
+	builder.commit()
+	list.evict(nil)
+	return
+}
+
+func writeCounted(w io.Writer, data []byte, n *int64) error {
+	m, err := w.Write(data)
+	*n += int64(m)
+	return err
+}
+
+// snapshotReader counts and checksums all bytes read.
+type snapshotReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+	n   int64
+	one [1]byte
+}
+
+func (sr *snapshotReader) Read(p []byte) (n int, err error) {
+	n, err = sr.r.Read(p)
+	sr.crc.Write(p[:n])
+	sr.n += int64(n)
+	return
+}
+
+func (sr *snapshotReader) ReadByte() (c byte, err error) {
+	if c, err = sr.r.ReadByte(); err != nil {
+		return
+	}
+	sr.one[0] = c
+	sr.crc.Write(sr.one[:])
+	sr.n++
+	return
+}
+
+// readRecord reads a length prefixed record into buf.
+func (sr *snapshotReader) readRecord(buf []byte) ([]byte, error) {
+	l, err := binary.ReadUvarint(sr)
+	if err != nil {
+		return buf, err
+	}
+	if l > maxSnapshotRecord {
+		return buf, ErrInvalidSnapshot
+	}
+	if uint64(cap(buf)) < l {
+		buf = make([]byte, l)
+	}
+	buf = buf[:l]
+	_, err = io.ReadFull(sr, buf)
+	return buf, err
+}
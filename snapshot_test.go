@@ -0,0 +1,89 @@
+package skiplist
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, string](NumberComparator[int], WithCodec[int, string](NumberCodec[int]{}, BytesCodec[string]{}))
+	for _, i := range rand.Perm(1000) {
+		list.Set(i-500, string(rune('a'+i%26)))
+	}
+	var buf bytes.Buffer
+	n, err := list.WriteTo(&buf)
+	a.NoError(err)
+	a.Equal(int64(buf.Len()), n)
+
+	loaded := New[int, string](NumberComparator[int], WithCodec[int, string](NumberCodec[int]{}, BytesCodec[string]{}), WithMutex())
+	loaded.Set(10000, "discarded")
+	n, err = loaded.ReadFrom(bytes.NewReader(buf.Bytes()))
+	a.NoError(err)
+	a.Equal(int64(buf.Len()), n)
+	a.Equal(list.Keys(), loaded.Keys())
+	a.Equal(list.Values(), loaded.Values())
+	assertSanity(a, loaded)
+	loaded.Set(1000, "z")
+	loaded.Remove(0)
+	assertSanity(a, loaded)
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	a := assert.New(t)
+	codec := WithCodec[float64, []byte](NumberCodec[float64]{}, BytesCodec[[]byte]{})
+	list := New[float64, []byte](NumberComparator[float64], codec)
+	list.Set(1.5, []byte("x"))
+	list.Set(-2.25, []byte("y"))
+	var buf bytes.Buffer
+	_, err := list.WriteTo(&buf)
+	a.NoError(err)
+	data := buf.Bytes()
+
+	_, err = New[int, int](NumberComparator[int]).WriteTo(&buf)
+	a.ErrorIs(err, ErrNoCodec)
+
+	loaded := New[float64, []byte](NumberComparator[float64], codec)
+	loaded.Set(3, nil)
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-5] ^= 0xff
+	_, err = loaded.ReadFrom(bytes.NewReader(corrupted))
+	a.ErrorIs(err, ErrChecksumMismatch)
+	_, err = loaded.ReadFrom(bytes.NewReader(data[:len(data)-2]))
+	a.ErrorIs(err, ErrInvalidSnapshot)
+	_, err = loaded.ReadFrom(bytes.NewReader([]byte("nope")))
+	a.ErrorIs(err, ErrInvalidSnapshot)
+	version := append([]byte{}, data...)
+	version[4] = 99
+	_, err = loaded.ReadFrom(bytes.NewReader(version))
+	a.ErrorIs(err, ErrUnsupportedVersion)
+	a.Equal([]float64{3}, loaded.Keys())
+
+	_, err = loaded.ReadFrom(bytes.NewReader(data))
+	a.NoError(err)
+	a.Equal([]float64{-2.25, 1.5}, loaded.Keys())
+	a.Equal([][]byte{[]byte("y"), []byte("x")}, loaded.Values())
+}
+
+func TestNumberCodec(t *testing.T) {
+	a := assert.New(t)
+	i8, err := NumberCodec[int8]{}.Encode(nil, -128)
+	a.NoError(err)
+	v8, err := NumberCodec[int8]{}.Decode(i8)
+	a.NoError(err)
+	a.Equal(int8(-128), v8)
+	u64, err := NumberCodec[uint64]{}.Encode(nil, 1<<63)
+	a.NoError(err)
+	vu64, err := NumberCodec[uint64]{}.Decode(u64)
+	a.NoError(err)
+	a.Equal(uint64(1<<63), vu64)
+	f32, err := NumberCodec[float32]{}.Encode(nil, 0.1)
+	a.NoError(err)
+	vf32, err := NumberCodec[float32]{}.Decode(f32)
+	a.NoError(err)
+	a.Equal(float32(0.1), vf32)
+	_, err = NumberCodec[int]{}.Decode(nil)
+	a.ErrorIs(err, ErrInvalidEncoding)
+}
@@ -0,0 +1,686 @@
+package skiplist
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"math"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// snapshotChunk is the number of elements a snapshot reads from the list while holding the read lock once.
+const snapshotChunk = 256
+
+// snapshotLog keeps the old states of elements changed while snapshots are open, so the snapshots still see them.
+//
+// Snapshot returns a view of the current version and starts a new version.
+// Every element is stamped with the version it was inserted or last changed at,
+// so a view sees the elements stamped with its version or older, and the old states which were current at its version.
+type snapshotLog[K, V any] struct {
+	version uint64
+	newest  uint64 // version of the newest snapshot.
+	undo    towers[undoKey[K], undoState[V]]
+
+	// lock guards open, which is also changed by finalizers of released views.
+	lock  sync.Mutex
+	open  map[uint64]int
+	count atomic.Int64
+	stale atomic.Bool // a view was released, old states are pruned by the next write.
+}
+
+// undoKey is an old state of the element with key and seq, which was current since version since.
+type undoKey[K any] struct {
+	key   K
+	seq   uint64
+	since uint64
+}
+
+// undoState is an old state of an element, which was changed at version changed.
+type undoState[V any] struct {
+	value    V
+	expireAt int64
+	changed  uint64
+}
+
+func newSnapshotLog[K, V any](comparable Comparable[K]) *snapshotLog[K, V] {
+	compare := func(a, b undoKey[K]) int {
+		if c := comparable(a.key, b.key); c != 0 {
+			return c
+		}
+		return cmp.Or(cmp.Compare(a.seq, b.seq), cmp.Compare(a.since, b.since))
+	}
+	return &snapshotLog[K, V]{
+		undo: newTowers[undoKey[K], undoState[V]](compare, newOptions(nil)),
+		open: make(map[uint64]int),
+	}
+}
+
+// release closes a view of version.
+func (s *snapshotLog[K, V]) release(version uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.open[version]--; s.open[version] == 0 {
+		delete(s.open, version)
+	}
+	s.count.Add(-1)
+	s.stale.Store(true)
+}
+
+// prune drops the old states no open view can see.
+//
+// The complexity is O(M*log(N)), M is the number of old states.
+func (s *snapshotLog[K, V]) prune() {
+	s.stale.Store(false)
+	s.lock.Lock()
+	versions := slices.Sorted(maps.Keys(s.open))
+	s.lock.Unlock()
+	if len(versions) == 0 {
+		s.undo.clear()
+		return
+	}
+	for node := s.undo.head.next[0]; node != nil; node = node.next[0] {
+		// the state is visible to versions in [since, changed).
+		i := sort.Search(len(versions), func(i int) bool { return versions[i] >= node.key.since })
+		if i == len(versions) || versions[i] >= node.data.changed {
+			s.undo.unlink(s.undo.search(node.key), node)
+		}
+	}
+}
+
+// set keeps state as the old state of key.
+func (s *snapshotLog[K, V]) set(key undoKey[K], state undoState[V]) {
+	prevs := s.undo.search(key)
+	if node := prevs[0].next[0]; node != nil && s.undo.comparable(node.key, key) == 0 {
+		node.data = state
+		return
+	}
+	node := s.undo.newTower(key)
+	node.data = state
+	s.undo.link(prevs, node)
+}
+
+// preserve keeps the current state of elem for open snapshots, it must be called before elem is changed or removed.
+func (list *skipListUnSafe[K, V]) preserve(elem *Element[K, V]) {
+	s := list.snapshots
+	if s == nil {
+		return
+	}
+	if s.stale.Load() {
+		s.prune()
+	}
+	if s.count.Load() > 0 && elem.version <= s.newest {
+		key := undoKey[K]{key: elem.key, seq: elem.seq, since: elem.version}
+		s.set(key, undoState[V]{value: elem.Value, expireAt: elem.expireAt, changed: s.version})
+	}
+	elem.version = s.version
+}
+
+// preserveAll keeps the current states of all elements for open snapshots, before all elements are discarded.
+func (list *skipListUnSafe[K, V]) preserveAll() {
+	if list.snapshots == nil {
+		return
+	}
+	for elem := list.next[0]; elem != nil; elem = elem.next[0] {
+		list.preserve(elem)
+	}
+}
+
+// stamp sets the insertion order and the version of a new element.
+func (list *skipListUnSafe[K, V]) stamp(elem *Element[K, V]) {
+	list.seq++
+	elem.seq = list.seq
+	if list.snapshots != nil {
+		elem.version = list.snapshots.version
+	}
+}
+
+// Snapshot returns a read-only view of the list at this point in time.
+// Later writes to the list don't affect the view. Elements of the view are copies,
+// changing their Value doesn't change the list, and Value of elements changed directly in the list is not tracked.
+//
+// Taking a snapshot is O(1). While snapshots are open, writes keep the old states of the elements they change,
+// until no open snapshot can see them. A snapshot is released when it and its elements are no longer reachable.
+// Lookups in the view are O(log(N)+M), M is the number of changes since the snapshot, and Next and Prev are lookups.
+// Index, GetByRank and CountRange walk the view, so they are O(N).
+func (list *skipListUnSafe[K, V]) Snapshot() ReadView[K, V] {
+	return list.snapshot(nil)
+}
+
+// snapshot returns a view of the list. lock is the read lock of the view, or nil.
+func (list *skipListUnSafe[K, V]) snapshot(lock *sync.RWMutex) *snapshotView[K, V] {
+	if list.snapshots == nil {
+		list.snapshots = newSnapshotLog[K, V](list.comparable)
+	}
+	s := list.snapshots
+	view := &snapshotView[K, V]{
+		list:     list,
+		lock:     lock,
+		version:  s.version,
+		length:   list.length,
+		maxLevel: list.maxLevel,
+	}
+	if list.expiry != nil {
+		view.now = list.expiry.now()
+	}
+	s.lock.Lock()
+	s.open[view.version]++
+	s.lock.Unlock()
+	s.count.Add(1)
+	s.newest = s.version
+	s.version++
+	version := view.version
+	runtime.SetFinalizer(view, func(*snapshotView[K, V]) { s.release(version) })
+	return view
+}
+
+// snapshotView is a read-only view of a list at a version.
+type snapshotView[K, V any] struct {
+	list     *skipListUnSafe[K, V]
+	lock     *sync.RWMutex
+	header   elementHeader[K, V] // empty header of all elements of the view.
+	version  uint64
+	now      int64 // elements expired at now are hidden.
+	length   int
+	maxLevel int
+}
+
+var _ = ReadView[int, int](&snapshotView[int, int]{})
+
+// viewPos is a position in a view, elements with equal keys are ordered by seq.
+type viewPos[K any] struct {
+	key K
+	seq uint64
+}
+
+type viewItem[K, V any] struct {
+	viewPos[K]
+	value V
+}
+
+func (view *snapshotView[K, V]) rlock() {
+	if view.lock != nil {
+		view.lock.RLock()
+	}
+}
+
+func (view *snapshotView[K, V]) runlock() {
+	if view.lock != nil {
+		view.lock.RUnlock()
+	}
+}
+
+func (view *snapshotView[K, V]) compare(a, b viewPos[K]) int {
+	if c := view.list.comparable(a.key, b.key); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.seq, b.seq)
+}
+
+// started returns true if p is at or past from in the direction of the walk.
+func (view *snapshotView[K, V]) started(p viewPos[K], from *viewPos[K], inclusive, forward bool) bool {
+	if from == nil {
+		return true
+	}
+	c := view.compare(p, *from)
+	if !forward {
+		c = -c
+	}
+	return c > 0 || c == 0 && inclusive
+}
+
+func (view *snapshotView[K, V]) alive(expireAt int64, expired bool) bool {
+	return expired || expireAt == 0 || expireAt > view.now
+}
+
+// walk calls f for elements of the view from the position from, forward or backward, until f returns false.
+// If from is nil, walks from the front or the back. If expired is true, expired elements are included.
+// The caller must hold the read lock.
+func (view *snapshotView[K, V]) walk(from *viewPos[K], inclusive, forward, expired bool, f func(item viewItem[K, V]) bool) {
+	list, undo := view.list, &view.list.snapshots.undo
+	var live *Element[K, V]
+	var old *tower[undoKey[K], undoState[V]]
+	switch {
+	case from == nil && forward:
+		live, old = list.next[0], undo.head.next[0]
+	case from == nil:
+		live, old = list.back, undo.back()
+	case forward:
+		live, old = list.findNext(nil, from.key), undo.find(undoKey[K]{key: from.key}, false)
+	default:
+		live = list.findPrev(from.key, true)
+		old = undo.last(undoKey[K]{key: from.key, seq: math.MaxUint64, since: math.MaxUint64}, true)
+	}
+	for {
+		for live != nil && !(live.version <= view.version && view.alive(live.expireAt, expired) &&
+			view.started(viewPos[K]{live.key, live.seq}, from, inclusive, forward)) {
+			if forward {
+				live = live.next[0]
+			} else {
+				live = live.prev
+			}
+		}
+		for old != nil && !(old.key.since <= view.version && view.version < old.data.changed && view.alive(old.data.expireAt, expired) &&
+			view.started(viewPos[K]{old.key.key, old.key.seq}, from, inclusive, forward)) {
+			if forward {
+				old = old.next[0]
+			} else {
+				old = undo.last(old.key, false)
+			}
+		}
+		if live == nil && old == nil {
+			return
+		}
+		var item viewItem[K, V]
+		if old != nil {
+			item = viewItem[K, V]{viewPos[K]{old.key.key, old.key.seq}, old.data.value}
+		}
+		if live != nil {
+			p := viewPos[K]{live.key, live.seq}
+			if c := view.compare(p, item.viewPos); old == nil || c < 0 == forward {
+				item = viewItem[K, V]{p, live.Value}
+			}
+		}
+		if !f(item) {
+			return
+		}
+		// later elements are past item.
+		from, inclusive = &item.viewPos, false
+	}
+}
+
+// first returns the first element of the view from the position from.
+func (view *snapshotView[K, V]) first(from *viewPos[K], inclusive, forward bool) *Element[K, V] {
+	view.rlock()
+	defer view.runlock()
+	var elem *Element[K, V]
+	view.walk(from, inclusive, forward, false, func(item viewItem[K, V]) bool {
+		elem = view.element(item)
+		return false
+	})
+	return elem
+}
+
+// scan works like walk, but takes the read lock itself, and releases it every snapshotChunk elements
+// so writers are not blocked for long. f is called without the lock.
+func (view *snapshotView[K, V]) scan(from *viewPos[K], inclusive, forward, expired bool, f func(item viewItem[K, V]) bool) {
+	items := make([]viewItem[K, V], 0, snapshotChunk)
+	for {
+		items = items[:0]
+		view.rlock()
+		view.walk(from, inclusive, forward, expired, func(item viewItem[K, V]) bool {
+			items = append(items, item)
+			return len(items) < snapshotChunk
+		})
+		view.runlock()
+		for _, item := range items {
+			if !f(item) {
+				return
+			}
+		}
+		if len(items) < snapshotChunk {
+			return
+		}
+		from, inclusive = &items[len(items)-1].viewPos, false
+	}
+}
+
+// element returns a new element of the view.
+func (view *snapshotView[K, V]) element(item viewItem[K, V]) *Element[K, V] {
+	return &Element[K, V]{
+		elementHeader: &view.header,
+		Value:         item.value,
+		key:           item.key,
+		seq:           item.seq,
+		list:          view,
+	}
+}
+
+func (view *snapshotView[K, V]) step(elem *Element[K, V], forward bool) *Element[K, V] {
+	return view.first(&viewPos[K]{elem.key, elem.seq}, false, forward)
+}
+
+// rangeStart returns the position to walk from for a range beginning at key.
+func rangeStart[K any](key K, exclusive, forward bool) (from *viewPos[K], inclusive bool) {
+	if exclusive == forward {
+		return &viewPos[K]{key, math.MaxUint64}, !exclusive
+	}
+	return &viewPos[K]{key, 0}, !exclusive
+}
+
+// Front returns the first element of the view.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Front() (front *Element[K, V]) {
+	return view.first(nil, false, true)
+}
+
+// Back returns the last element of the view.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Back() *Element[K, V] {
+	return view.first(nil, false, false)
+}
+
+// Len returns element count of the view, counting expired elements like the list does.
+//
+// The complexity is O(1).
+func (view *snapshotView[K, V]) Len() int {
+	return view.length
+}
+
+// FindNext returns the first element after start that is greater or equal to key.
+// If start is greater or equal to key, returns start.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) FindNext(start *Element[K, V], key K) (next *Element[K, V]) {
+	if start != nil && view.list.comparable(key, start.key) <= 0 {
+		return start
+	}
+	return view.Find(key)
+}
+
+// Find returns the first element that is greater or equal to key.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Find(key K) (elem *Element[K, V]) {
+	return view.first(&viewPos[K]{key, 0}, true, true)
+}
+
+// FindPrev returns the last element before start that is less or equal to key.
+// If start is less or equal to key, returns start.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) FindPrev(start *Element[K, V], key K) (prev *Element[K, V]) {
+	if start != nil && view.list.comparable(start.key, key) <= 0 {
+		return start
+	}
+	return view.Floor(key)
+}
+
+// Floor returns the last element that is less or equal to key.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Floor(key K) (elem *Element[K, V]) {
+	return view.first(&viewPos[K]{key, math.MaxUint64}, true, false)
+}
+
+// Lower returns the last element that is less than key.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Lower(key K) (elem *Element[K, V]) {
+	return view.first(&viewPos[K]{key, 0}, false, false)
+}
+
+// Higher returns the first element that is greater than key.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Higher(key K) (elem *Element[K, V]) {
+	return view.first(&viewPos[K]{key, math.MaxUint64}, false, true)
+}
+
+// Range calls f sequentially for each element with key between from and to, until f returns false.
+// f is called without the read lock, so it may modify the list.
+//
+// The complexity is O(log(N)+M+U), M is the number of visited elements, U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Range(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption) {
+	option := newRangeOptions(options)
+	start, inclusive := rangeStart(from, option.exclusiveFrom, true)
+	view.scan(start, inclusive, true, false, func(item viewItem[K, V]) bool {
+		c := view.list.comparable(item.key, to)
+		if c > 0 || c == 0 && option.exclusiveTo {
+			return false
+		}
+		return f(view.element(item))
+	})
+}
+
+// ReverseRange calls f sequentially for each element with key between from and to in reverse order,
+// until f returns false. f is called without the read lock, so it may modify the list.
+//
+// The complexity is O(log(N)+M+U), M is the number of visited elements, U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) ReverseRange(from, to K, f func(elem *Element[K, V]) bool, options ...RangeOption) {
+	option := newRangeOptions(options)
+	start, inclusive := rangeStart(from, option.exclusiveFrom, false)
+	view.scan(start, inclusive, false, false, func(item viewItem[K, V]) bool {
+		c := view.list.comparable(item.key, to)
+		if c < 0 || c == 0 && option.exclusiveTo {
+			return false
+		}
+		return f(view.element(item))
+	})
+}
+
+// Get returns an element with the key.
+// If the key is not found, returns nil.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Get(key K) (elem *Element[K, V]) {
+	if elem = view.Find(key); elem != nil && view.list.comparable(elem.key, key) == 0 {
+		return elem
+	}
+	return nil
+}
+
+// GetAll returns all elements with the key in insertion order.
+//
+// The complexity is O(log(N)+M+U), M is the number of elements with the key, U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) GetAll(key K) (elems []*Element[K, V]) {
+	view.Range(key, key, func(elem *Element[K, V]) bool {
+		elems = append(elems, elem)
+		return true
+	})
+	return
+}
+
+// GetValue returns value of the element with the key.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) GetValue(key K) (val V, ok bool) {
+	if elem := view.Get(key); elem != nil {
+		return elem.Value, true
+	}
+	return
+}
+
+// MustGetValue returns value of the element with the key.
+// It will panic if the key doesn't exist in the view.
+//
+// The complexity is O(log(N)+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) MustGetValue(key K) V {
+	elem := view.Get(key)
+	if elem == nil {
+		panic(fmt.Errorf("skiplist: cannot find key `%v` in skiplist", key))
+	}
+	return elem.Value
+}
+
+// CountRange returns the number of elements with key between from and to.
+// Expired elements are counted like the list does, so the elements in range are walked.
+//
+// The complexity is O(log(N)+M+U), M is the number of counted elements, U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) CountRange(from, to K, options ...RangeOption) (count int) {
+	option := newRangeOptions(options)
+	start, inclusive := rangeStart(from, option.exclusiveFrom, true)
+	view.scan(start, inclusive, true, true, func(item viewItem[K, V]) bool {
+		c := view.list.comparable(item.key, to)
+		if c > 0 || c == 0 && option.exclusiveTo {
+			return false
+		}
+		count++
+		return true
+	})
+	return
+}
+
+// MaxLevel returns the max level of the list when the snapshot was taken.
+//
+// The complexity is O(1).
+func (view *snapshotView[K, V]) MaxLevel() int {
+	return view.maxLevel
+}
+
+// Values returns list of values
+//
+// The complexity is O(N+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Values() (values []V) {
+	for _, v := range view.All() {
+		values = append(values, v)
+	}
+	return
+}
+
+// Index returns index of element, or -1 if it's not in the view.
+// Expired elements are counted like the list does.
+//
+// The complexity is O(N+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Index(elem *Element[K, V]) (i int) {
+	if elem == nil || elem.list != ReadView[K, V](view) {
+		return -1
+	}
+	target := viewPos[K]{elem.key, elem.seq}
+	i = -1
+	rank := 0
+	view.scan(nil, false, true, true, func(item viewItem[K, V]) bool {
+		c := view.compare(item.viewPos, target)
+		if c == 0 {
+			i = rank
+		}
+		rank++
+		return c < 0
+	})
+	return
+}
+
+// GetByRank returns the element at index i, a negative i counts from the back.
+// Expired elements are counted like the list does.
+//
+// The complexity is O(N+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) GetByRank(i int) (elem *Element[K, V]) {
+	if i < 0 {
+		i += view.length
+	}
+	if i < 0 || i >= view.length {
+		return nil
+	}
+	rank := 0
+	view.scan(nil, false, true, true, func(item viewItem[K, V]) bool {
+		if rank == i {
+			elem = view.element(item)
+			return false
+		}
+		rank++
+		return true
+	})
+	return
+}
+
+// Keys returns list of keys
+//
+// The complexity is O(N+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Keys() (keys []K) {
+	for k := range view.All() {
+		keys = append(keys, k)
+	}
+	return
+}
+
+func (view *snapshotView[K, V]) seq(from *viewPos[K], inclusive, forward bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		view.scan(from, inclusive, forward, false, func(item viewItem[K, V]) bool {
+			return yield(item.key, item.value)
+		})
+	}
+}
+
+// All returns an iterator over key-value pairs from front to back.
+// It merges the live elements with the undo log, and releases the read lock every few hundred elements.
+//
+// The complexity is O(N+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) All() iter.Seq2[K, V] {
+	return view.seq(nil, false, true)
+}
+
+// Backward returns an iterator over key-value pairs from back to front.
+// It merges the live elements with the undo log, and releases the read lock every few hundred elements.
+//
+// The complexity is O(N+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Backward() iter.Seq2[K, V] {
+	return view.seq(nil, false, false)
+}
+
+// Ascend returns an iterator over key-value pairs greater or equal to from, in ascending order.
+//
+// The complexity is O(log(N)+M+U), M is the number of visited pairs, U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Ascend(from K) iter.Seq2[K, V] {
+	return view.seq(&viewPos[K]{from, 0}, true, true)
+}
+
+// Descend returns an iterator over key-value pairs less or equal to from, in descending order.
+//
+// The complexity is O(log(N)+M+U), M is the number of visited pairs, U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Descend(from K) iter.Seq2[K, V] {
+	return view.seq(&viewPos[K]{from, math.MaxUint64}, true, false)
+}
+
+// KeysSeq returns an iterator over keys from front to back.
+//
+// The complexity is O(N+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range view.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over values from front to back.
+//
+// The complexity is O(N+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) ValuesSeq() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range view.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// count returns the number of elements in the view which haven't expired.
+func (view *snapshotView[K, V]) count() (count int) {
+	view.scan(nil, false, true, false, func(viewItem[K, V]) bool {
+		count++
+		return true
+	})
+	return
+}
+
+// WriteTo writes the view to w using the codecs of the list, see WithCodec.
+// The view is walked twice, to count the elements and to write them.
+//
+// The complexity is O(N+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) WriteTo(w io.Writer) (n int64, err error) {
+	return view.list.writeSnapshot(w, view.count(), view.All())
+}
+
+// Flush writes the view to w as a sorted table using the codecs of the list, see WithCodec.
+// The view is walked twice, to count the elements and to write them. Tombstones are not written.
+//
+// The complexity is O(N+U), U is the number of old states in the undo log.
+func (view *snapshotView[K, V]) Flush(w io.Writer, options ...TableOption) (n int64, err error) {
+	return view.list.flushTable(w, view.count(), 0, func(yield func(K, tableEntry[V]) bool) {
+		for key, value := range view.All() {
+			if !yield(key, tableEntry[V]{value: value}) {
+				return
+			}
+		}
+	}, options)
+}
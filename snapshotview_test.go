@@ -0,0 +1,146 @@
+package skiplist
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+)
+
+type snapshotPair struct {
+	key, value int
+}
+
+func pairsOf(view ReadView[int, int]) (pairs []snapshotPair) {
+	for k, v := range view.All() {
+		pairs = append(pairs, snapshotPair{k, v})
+	}
+	return
+}
+
+func assertView(a *assert.Assertions, expected []snapshotPair, view ReadView[int, int]) {
+	a.Equal(expected, pairsOf(view))
+	var backward []snapshotPair
+	for elem := view.Back(); elem != nil; elem = elem.Prev() {
+		backward = append([]snapshotPair{{elem.Key(), elem.Value}}, backward...)
+	}
+	a.Equal(expected, backward)
+	for i, pair := range expected {
+		elem := view.GetByRank(i)
+		a.Equal(pair, snapshotPair{elem.Key(), elem.Value})
+		a.Equal(i, view.Index(elem))
+		if i == 0 || expected[i-1].key != pair.key {
+			a.Equal(elem, view.Find(pair.key))
+			a.Equal(elem.Value, view.MustGetValue(pair.key))
+		}
+	}
+	if len(expected) > 0 {
+		lo, hi := expected[0].key, expected[len(expected)-1].key
+		a.Nil(view.Lower(lo))
+		a.Nil(view.Higher(hi))
+		a.Equal(len(expected), view.CountRange(lo, hi))
+	}
+}
+
+func TestSnapshotView(t *testing.T) {
+	for _, duplicates := range []bool{false, true} {
+		a := assert.New(t)
+		options := []Option{WithCodec[int, int](NumberCodec[int]{}, NumberCodec[int]{})}
+		if duplicates {
+			options = append(options, WithDuplicates())
+		}
+		list := New[int, int](NumberComparator[int], options...)
+		var views []ReadView[int, int]
+		var expected [][]snapshotPair
+		for i := 0; i < 3000; i++ {
+			key := rand.Intn(200)
+			switch rand.Intn(10) {
+			case 0, 1:
+				list.Remove(key)
+			case 2:
+				list.RemoveRange(key, key+5)
+			case 3:
+				if elem := list.Get(key); elem != nil {
+					list.RemoveElement(elem)
+				}
+			case 4:
+				list.Compute(key, func(old int, exists bool) (int, ComputeAction) {
+					return old + 1, ComputeUpdate
+				})
+			default:
+				list.Set(key, i)
+			}
+			if i%300 == 0 {
+				views = append(views, list.Snapshot())
+				expected = append(expected, pairsOf(list))
+			}
+		}
+		for i, view := range views {
+			assertView(a, expected[i], view)
+			a.Equal(len(expected[i]), view.Len())
+		}
+
+		// Init and ReadFrom replace all elements.
+		view := list.Snapshot()
+		before := pairsOf(list)
+		var buf bytes.Buffer
+		_, err := list.WriteTo(&buf)
+		a.NoError(err)
+		list.Init()
+		list.Set(-1, -1)
+		assertView(a, before, view)
+		a.Equal([]snapshotPair{{-1, -1}}, pairsOf(list))
+		view = list.Snapshot()
+		_, err = list.ReadFrom(&buf)
+		a.NoError(err)
+		assertView(a, []snapshotPair{{-1, -1}}, view)
+		assertView(a, before, list.Snapshot())
+		assertSanity(a, list)
+	}
+}
+
+func TestSnapshotViewExpiry(t *testing.T) {
+	a := assert.New(t)
+	clock := &fakeClock{}
+	list := New[int, int](NumberComparator[int], WithExpiry[int, int](clock.Now, nil))
+	list.Set(1, 1)
+	list.SetWithTTL(2, 2, time.Second)
+	list.Set(3, 3)
+	view := list.Snapshot()
+	clock.Advance(time.Second)
+	list.Set(1, 10)
+	// elements expired after the snapshot are visible in it.
+	assertView(a, []snapshotPair{{1, 1}, {2, 2}, {3, 3}}, view)
+	view = list.Snapshot()
+	list.RemoveExpired()
+	a.Equal([]snapshotPair{{1, 10}, {3, 3}}, pairsOf(view))
+	a.Equal(3, view.Len())
+	a.Equal(3, view.CountRange(1, 3))
+}
+
+func TestSnapshotViewRelease(t *testing.T) {
+	a := assert.New(t)
+	list := New[int, int](NumberComparator[int])
+	for i := 0; i < 100; i++ {
+		list.Set(i, i)
+	}
+	view := list.Snapshot()
+	for i := 0; i < 100; i++ {
+		list.Set(i, -i)
+	}
+	a.Equal(0, view.Front().Value)
+	inner, _ := unsafeListOf(list)
+	undo := &inner.snapshots.undo
+	a.NotNil(undo.head.next[0])
+
+	view = nil
+	for i := 0; i < 100 && undo.head.next[0] != nil; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+		list.Set(0, i)
+	}
+	// released views no longer keep old states.
+	a.Nil(undo.head.next[0])
+}
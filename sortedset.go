@@ -0,0 +1,234 @@
+package skiplist
+
+import (
+	"fmt"
+	"iter"
+)
+
+// AddFlag changes the behavior of SortedSet.Add, like the flags of Redis ZADD.
+type AddFlag int
+
+const (
+	// AddNX only adds new members, existing members are not updated.
+	AddNX AddFlag = 1 << iota
+	// AddXX only updates existing members, new members are not added.
+	AddXX
+	// AddGT only updates existing members if the new score is greater than the current score.
+	AddGT
+	// AddLT only updates existing members if the new score is less than the current score.
+	AddLT
+)
+
+type scoredMember[M, S any] struct {
+	member M
+	score  S
+}
+
+// SortedSet is a set of unique members ordered by score, like Redis sorted sets.
+// Members with equal scores are ordered by the member comparable.
+// A map from member to score makes score lookups O(1), and a skip list ordered by (score, member) keeps ranks.
+//
+// SortedSet is not safe for concurrent use.
+type SortedSet[M comparable, S Numbers] struct {
+	scores map[M]S
+	list   *skipListUnSafe[scoredMember[M, S], struct{}]
+	member Comparable[M]
+}
+
+// NewSortedSet creates a new sorted set with member to order members with equal scores.
+// Only WithMaxLevel and WithProbability are used, other options are ignored.
+func NewSortedSet[M comparable, S Numbers](member Comparable[M], options ...Option) *SortedSet[M, S] {
+	option := newOptions(options)
+	set := &SortedSet[M, S]{
+		scores: make(map[M]S),
+		member: member,
+	}
+	set.list = newSkipListUnSafe[scoredMember[M, S], struct{}](set.compare, newOptions([]Option{WithMaxLevel(option.maxLevel), WithProbability(option.probability)}))
+	return set
+}
+
+// Len returns member count in this set, like ZCARD.
+//
+// The complexity is O(1).
+func (set *SortedSet[M, S]) Len() int {
+	return len(set.scores)
+}
+
+// Add adds member with score or updates the score of an existing member, like ZADD.
+// flags restrict when members are added or updated, AddNX can't be combined with other flags
+// and AddGT can't be combined with AddLT.
+// Returns added if the member is new, updated if the score of an existing member changed.
+// Add panics if score is NaN, which can't be ordered.
+//
+// The complexity is O(log(N)).
+func (set *SortedSet[M, S]) Add(member M, score S, flags ...AddFlag) (added, updated bool) {
+	var flag AddFlag
+	for _, f := range flags {
+		flag |= f
+	}
+	if flag&AddNX != 0 && flag&(AddXX|AddGT|AddLT) != 0 || flag&AddGT != 0 && flag&AddLT != 0 {
+		panic(fmt.Errorf("skiplist: AddNX can't be combined with other flags, and AddGT can't be combined with AddLT"))
+	}
+	if isNaN(score) {
+		panic(fmt.Errorf("skiplist: score is NaN"))
+	}
+	old, exists := set.scores[member]
+	if !exists {
+		if flag&AddXX != 0 {
+			return
+		}
+		set.scores[member] = score
+		set.list.Set(scoredMember[M, S]{member: member, score: score}, struct{}{})
+		return true, false
+	}
+	if flag&AddNX != 0 || old == score || flag&AddGT != 0 && score <= old || flag&AddLT != 0 && score >= old {
+		return
+	}
+	set.update(member, old, score)
+	return false, true
+}
+
+// IncrBy adds delta to the score of member, like ZINCRBY.
+// If the member doesn't exist, it's added with delta as score.
+// Returns the new score.
+// IncrBy panics if the new score is NaN, e.g. adding -Inf to +Inf, and the score is left unchanged.
+//
+// The complexity is O(log(N)).
+func (set *SortedSet[M, S]) IncrBy(member M, delta S) S {
+	old, exists := set.scores[member]
+	if !exists {
+		set.Add(member, delta)
+		return delta
+	}
+	score := old + delta
+	if isNaN(score) {
+		panic(fmt.Errorf("skiplist: score is NaN"))
+	}
+	if delta != 0 {
+		set.update(member, old, score)
+	}
+	return score
+}
+
+// isNaN returns true if score is a floating point NaN, the only value not equal to itself.
+func isNaN[S Numbers](score S) bool {
+	return score != score
+}
+
+// Remove removes member, like ZREM.
+// Returns true if the member existed.
+//
+// The complexity is O(log(N)).
+func (set *SortedSet[M, S]) Remove(member M) bool {
+	score, exists := set.scores[member]
+	if !exists {
+		return false
+	}
+	delete(set.scores, member)
+	set.list.Remove(scoredMember[M, S]{member: member, score: score})
+	return true
+}
+
+// Score returns the score of member, like ZSCORE.
+//
+// The complexity is O(1).
+func (set *SortedSet[M, S]) Score(member M) (score S, ok bool) {
+	score, ok = set.scores[member]
+	return
+}
+
+// Rank returns the index of member in ascending order, like ZRANK.
+//
+// The complexity is O(log(N)).
+func (set *SortedSet[M, S]) Rank(member M) (rank int, ok bool) {
+	score, exists := set.scores[member]
+	if !exists {
+		return -1, false
+	}
+	return set.list.rankPrev(scoredMember[M, S]{member: member, score: score}, false), true
+}
+
+// RevRank returns the index of member in descending order, like ZREVRANK.
+//
+// The complexity is O(log(N)).
+func (set *SortedSet[M, S]) RevRank(member M) (rank int, ok bool) {
+	if rank, ok = set.Rank(member); !ok {
+		return -1, false
+	}
+	return set.Len() - 1 - rank, true
+}
+
+// All returns an iterator over members and scores in ascending order.
+func (set *SortedSet[M, S]) All() iter.Seq2[M, S] {
+	return set.iterate(set.list.Front(), func(scoredMember[M, S]) bool { return true })
+}
+
+// RangeByScore returns an iterator over members with score between min and max in ascending order, like ZRANGEBYSCORE.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+//
+// The complexity is O(log(N)+M), M is the number of visited members.
+func (set *SortedSet[M, S]) RangeByScore(min, max S, options ...RangeOption) iter.Seq2[M, S] {
+	option := newRangeOptions(options)
+	return func(yield func(M, S) bool) {
+		start := set.first(func(key scoredMember[M, S]) bool {
+			return key.score < min || key.score == min && option.exclusiveFrom
+		})
+		set.iterate(start, func(key scoredMember[M, S]) bool {
+			return key.score < max || key.score == max && !option.exclusiveTo
+		})(yield)
+	}
+}
+
+// RangeByLex returns an iterator over members between min and max in ascending order, like ZRANGEBYLEX.
+// Both bounds are inclusive unless WithExclusiveFrom or WithExclusiveTo is given.
+// Like Redis, it expects all members to have the same score, otherwise the result is unspecified.
+//
+// The complexity is O(log(N)+M), M is the number of visited members.
+func (set *SortedSet[M, S]) RangeByLex(min, max M, options ...RangeOption) iter.Seq2[M, S] {
+	option := newRangeOptions(options)
+	return func(yield func(M, S) bool) {
+		start := set.first(func(key scoredMember[M, S]) bool {
+			c := set.member(key.member, min)
+			return c < 0 || c == 0 && option.exclusiveFrom
+		})
+		set.iterate(start, func(key scoredMember[M, S]) bool {
+			c := set.member(key.member, max)
+			return c < 0 || c == 0 && !option.exclusiveTo
+		})(yield)
+	}
+}
+
+// first returns the first element for which before returns false, before must be true only for a prefix of the list.
+func (set *SortedSet[M, S]) first(before func(key scoredMember[M, S]) bool) *Element[scoredMember[M, S], struct{}] {
+	prev := &set.list.elementHeader
+	for i := set.list.maxLevel - 1; i >= 0; i-- {
+		for next := prev.next[i]; next != nil && before(next.key); next = prev.next[i] {
+			prev = next.elementHeader
+		}
+	}
+	return prev.next[0]
+}
+
+// iterate returns an iterator from start while in returns true.
+func (set *SortedSet[M, S]) iterate(start *Element[scoredMember[M, S], struct{}], in func(key scoredMember[M, S]) bool) iter.Seq2[M, S] {
+	return func(yield func(M, S) bool) {
+		for elem := start; elem != nil && in(elem.key); elem = elem.Next() {
+			if !yield(elem.key.member, elem.key.score) {
+				return
+			}
+		}
+	}
+}
+
+func (set *SortedSet[M, S]) update(member M, old, score S) {
+	set.scores[member] = score
+	set.list.Remove(scoredMember[M, S]{member: member, score: old})
+	set.list.Set(scoredMember[M, S]{member: member, score: score}, struct{}{})
+}
+
+func (set *SortedSet[M, S]) compare(lhs, rhs scoredMember[M, S]) int {
+	if c := NumberComparator(lhs.score, rhs.score); c != 0 {
+		return c
+	}
+	return set.member(lhs.member, rhs.member)
+}
@@ -0,0 +1,102 @@
+package skiplist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func collectSet[M comparable, S Numbers](seq func(yield func(M, S) bool)) (members []M) {
+	for m := range seq {
+		members = append(members, m)
+	}
+	return
+}
+
+func TestSortedSet(t *testing.T) {
+	a := assert.New(t)
+	set := NewSortedSet[string, float64](BytesComparator[string])
+
+	added, updated := set.Add("alice", 10)
+	a.True(added)
+	a.False(updated)
+	set.Add("bob", 20)
+	set.Add("carol", 20)
+	set.Add("dave", 5)
+	a.Equal(4, set.Len())
+	a.Equal([]string{"dave", "alice", "bob", "carol"}, collectSet(set.All()))
+
+	added, updated = set.Add("alice", 10)
+	a.False(added)
+	a.False(updated)
+	added, updated = set.Add("alice", 30, AddNX)
+	a.False(added || updated)
+	added, updated = set.Add("erin", 30, AddXX)
+	a.False(added || updated)
+	_, ok := set.Score("erin")
+	a.False(ok)
+	added, updated = set.Add("alice", 1, AddXX, AddGT)
+	a.False(added || updated)
+	added, updated = set.Add("alice", 25, AddGT)
+	a.True(updated)
+	added, updated = set.Add("alice", 30, AddLT)
+	a.False(updated)
+	added, updated = set.Add("erin", 30, AddLT)
+	a.True(added)
+	a.Panics(func() { set.Add("alice", 1, AddNX, AddGT) })
+	a.Panics(func() { set.Add("alice", 1, AddGT, AddLT) })
+
+	score, ok := set.Score("alice")
+	a.True(ok)
+	a.Equal(25.0, score)
+	a.Equal([]string{"dave", "bob", "carol", "alice", "erin"}, collectSet(set.All()))
+
+	a.Equal(26.0, set.IncrBy("dave", 21))
+	a.Equal(3.0, set.IncrBy("frank", 3))
+	a.Equal([]string{"frank", "bob", "carol", "alice", "dave", "erin"}, collectSet(set.All()))
+
+	rank, ok := set.Rank("carol")
+	a.True(ok)
+	a.Equal(2, rank)
+	rank, ok = set.RevRank("carol")
+	a.True(ok)
+	a.Equal(3, rank)
+	rank, ok = set.Rank("nobody")
+	a.False(ok)
+	a.Equal(-1, rank)
+
+	a.Equal([]string{"bob", "carol", "alice"}, collectSet(set.RangeByScore(20, 25)))
+	a.Equal([]string{"alice", "dave"}, collectSet(set.RangeByScore(20, 30, WithExclusiveFrom(), WithExclusiveTo())))
+	a.Empty(collectSet(set.RangeByScore(31, 40)))
+
+	a.True(set.Remove("bob"))
+	a.False(set.Remove("bob"))
+	a.Equal(5, set.Len())
+	a.Equal([]string{"frank", "carol", "alice", "dave", "erin"}, collectSet(set.All()))
+	rank, _ = set.Rank("erin")
+	a.Equal(4, rank)
+
+	// NaN scores can't be ordered.
+	a.PanicsWithError("skiplist: score is NaN", func() { set.Add("nan", math.NaN()) })
+	a.PanicsWithError("skiplist: score is NaN", func() { set.IncrBy("nan", math.NaN()) })
+	set.Add("inf", math.Inf(1))
+	a.PanicsWithError("skiplist: score is NaN", func() { set.IncrBy("inf", math.Inf(-1)) })
+	score, _ = set.Score("inf")
+	a.Equal(math.Inf(1), score)
+	_, ok = set.Score("nan")
+	a.False(ok)
+	a.Equal(6, set.Len())
+	assertSanity(a, SkipList[scoredMember[string, float64], struct{}](set.list))
+}
+
+func TestSortedSetRangeByLex(t *testing.T) {
+	a := assert.New(t)
+	set := NewSortedSet[string, int](BytesComparator[string])
+	for _, m := range []string{"g", "a", "e", "c", "b", "f", "d"} {
+		set.Add(m, 0)
+	}
+	a.Equal([]string{"b", "c", "d"}, collectSet(set.RangeByLex("b", "d")))
+	a.Equal([]string{"c"}, collectSet(set.RangeByLex("b", "d", WithExclusiveFrom(), WithExclusiveTo())))
+	a.Equal([]string{"e", "f", "g"}, collectSet(set.RangeByLex("dd", "z")))
+	a.Empty(collectSet(set.RangeByLex("x", "z")))
+}
@@ -0,0 +1,523 @@
+package skiplist
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"iter"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Sorted table format:
+//
+//	blocks  data blocks of records (kind byte, uvarint key length, key, and for values uvarint value length, value)
+//	index   one entry per block (uvarint key length, first key, uvarint offset, uvarint length)
+//	bloom   optional bloom filter over the keys of all records (hash count byte, bits)
+//	footer  index offset, index length, bloom offset, bloom length, count and tombstone count as big-endian uint64,
+//	        then version byte and magic "SKTB"
+//
+// A record is a value or a tombstone, which marks the key as removed, see WithTombstones.
+//
+// Every block, the index and the bloom filter end with a big-endian crc32 IEEE checksum of their contents,
+// lengths in the index and the footer include the checksum.
+const tableVersion = 1
+
+// tableFooterSize is the size of the fixed footer at the end of a table.
+const tableFooterSize = 6*8 + 1 + 4
+
+// Kinds of table records.
+const (
+	tableValue     byte = 0
+	tableTombstone byte = 1
+)
+
+var tableMagic = [4]byte{'S', 'K', 'T', 'B'}
+
+// ErrInvalidTable is returned by OpenTable and TableReader if the table is corrupted.
+var ErrInvalidTable = errors.New("skiplist: invalid table")
+
+// Flush writes the list to w as a sorted table using the codecs given by WithCodec.
+// A list created WithTombstones also writes tombstones of removed keys.
+// Use OpenTable to read the table.
+//
+// The complexity is O(N+T), T is the number of tombstones.
+func (list *skipListUnSafe[K, V]) Flush(w io.Writer, options ...TableOption) (n int64, err error) {
+	count, deleted, entries := list.tableEntries()
+	return list.flushTable(w, count, deleted, entries, options)
+}
+
+// flushTable writes count values and deleted tombstones of entries as a sorted table to w.
+func (list *skipListUnSafe[K, V]) flushTable(w io.Writer, count, deleted int, entries iter.Seq2[K, tableEntry[V]], options []TableOption) (n int64, err error) {
+	if list.keyCodec == nil || list.valueCodec == nil {
+		return 0, ErrNoCodec
+	}
+	option := newTableOptions(options)
+	var bloom *bloomFilter
+	if option.bloomBitsPerKey > 0 {
+		bloom = newBloomFilter(count+deleted, option.bloomBitsPerKey)
+	}
+	bw := bufio.NewWriter(w)
+	var block, index, data, first []byte
+	writeBlock := func() error {
+		if len(block) == 0 {
+			return nil
+		}
+		index = binary.AppendUvarint(index, uint64(len(first)))
+		index = append(index, first...)
+		index = binary.AppendUvarint(index, uint64(n))
+		index = binary.AppendUvarint(index, uint64(len(block)+crc32.Size))
+		block = binary.BigEndian.AppendUint32(block, crc32.ChecksumIEEE(block))
+		err := writeCounted(bw, block, &n)
+		block = block[:0]
+		return err
+	}
+	for key, entry := range entries {
+		if data, err = list.keyCodec.Encode(data[:0], key); err != nil {
+			return
+		}
+		if len(block) == 0 {
+			first = append(first[:0], data...)
+		}
+		if bloom != nil {
+			bloom.add(data)
+		}
+		if entry.deleted {
+			block = append(block, tableTombstone)
+		} else {
+			block = append(block, tableValue)
+		}
+		block = binary.AppendUvarint(block, uint64(len(data)))
+		block = append(block, data...)
+		if !entry.deleted {
+			if data, err = list.valueCodec.Encode(data[:0], entry.value); err != nil {
+				return
+			}
+			block = binary.AppendUvarint(block, uint64(len(data)))
+			block = append(block, data...)
+		}
+		if len(block) >= option.blockSize {
+			if err = writeBlock(); err != nil {
+				return
+			}
+		}
+	}
+	if err = writeBlock(); err != nil {
+		return
+	}
+
+	footer := make([]byte, 0, tableFooterSize)
+	footer = binary.BigEndian.AppendUint64(footer, uint64(n))
+	footer = binary.BigEndian.AppendUint64(footer, uint64(len(index)+crc32.Size))
+	index = binary.BigEndian.AppendUint32(index, crc32.ChecksumIEEE(index))
+	if err = writeCounted(bw, index, &n); err != nil {
+		return
+	}
+	footer = binary.BigEndian.AppendUint64(footer, uint64(n))
+	if bloom != nil {
+		data = append(append(data[:0], bloom.k), bloom.bits...)
+		data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+		if err = writeCounted(bw, data, &n); err != nil {
+			return
+		}
+		footer = binary.BigEndian.AppendUint64(footer, uint64(len(data)))
+	} else {
+		footer = binary.BigEndian.AppendUint64(footer, 0)
+	}
+	footer = binary.BigEndian.AppendUint64(footer, uint64(count))
+	footer = binary.BigEndian.AppendUint64(footer, uint64(deleted))
+	footer = append(footer, tableVersion)
+	footer = append(footer, tableMagic[:]...)
+	if err = writeCounted(bw, footer, &n); err != nil {
+		return
+	}
+	err = bw.Flush()
+	return
+}
+
+// TableReader reads a sorted table written by Flush.
+// The index and the bloom filter are kept in memory, data blocks are read from the table on demand.
+// It's safe for concurrent use if the underlying io.ReaderAt is.
+type TableReader[K, V any] struct {
+	r          io.ReaderAt
+	comparable Comparable[K]
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+	firstKeys  []K
+	blocks     []tableBlock
+	bloom      *bloomFilter
+	length     int
+	deleted    int
+
+	errLock sync.Mutex
+	err     error
+}
+
+type tableBlock struct {
+	offset uint64
+	length uint64
+}
+
+// OpenTable opens a sorted table of size bytes from r.
+// comparable and the codecs must match the list the table was written from.
+func OpenTable[K, V any](r io.ReaderAt, size int64, comparable Comparable[K], keyCodec Codec[K], valueCodec Codec[V]) (*TableReader[K, V], error) {
+	if size < tableFooterSize {
+		return nil, ErrInvalidTable
+	}
+	footer := make([]byte, tableFooterSize)
+	if err := readAt(r, footer, size-tableFooterSize); err != nil {
+		return nil, err
+	}
+	if [4]byte(footer[tableFooterSize-4:]) != tableMagic {
+		return nil, ErrInvalidTable
+	}
+	if version := footer[tableFooterSize-5]; version != tableVersion {
+		return nil, fmt.Errorf("%w: unsupported version %v", ErrInvalidTable, version)
+	}
+	table := &TableReader[K, V]{
+		r:          r,
+		comparable: comparable,
+		keyCodec:   keyCodec,
+		valueCodec: valueCodec,
+	}
+	var fields [6]uint64
+	for i := range fields {
+		fields[i] = binary.BigEndian.Uint64(footer[i*8:])
+	}
+	indexOffset, indexLength, bloomOffset, bloomLength, count, deleted := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+	limit := uint64(size - tableFooterSize)
+	if indexOffset > limit || indexLength > limit-indexOffset || bloomOffset > limit || bloomLength > limit-bloomOffset ||
+		count > math.MaxInt || deleted > math.MaxInt {
+		return nil, ErrInvalidTable
+	}
+	table.length = int(count)
+	table.deleted = int(deleted)
+
+	index, err := table.readBlock(tableBlock{offset: indexOffset, length: indexLength})
+	if err != nil {
+		return nil, err
+	}
+	for len(index) > 0 {
+		var key K
+		if key, index, err = decodeRecord(index, keyCodec); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTable, err)
+		}
+		offset, n := binary.Uvarint(index)
+		if n <= 0 {
+			return nil, ErrInvalidTable
+		}
+		index = index[n:]
+		length, n := binary.Uvarint(index)
+		if n <= 0 || offset > indexOffset || length > indexOffset-offset {
+			return nil, ErrInvalidTable
+		}
+		index = index[n:]
+		table.firstKeys = append(table.firstKeys, key)
+		table.blocks = append(table.blocks, tableBlock{offset: offset, length: length})
+	}
+
+	if bloomLength > 0 {
+		data, err := table.readBlock(tableBlock{offset: bloomOffset, length: bloomLength})
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < 2 {
+			return nil, ErrInvalidTable
+		}
+		table.bloom = &bloomFilter{k: data[0], bits: data[1:]}
+	}
+	return table, nil
+}
+
+// Len returns element count in this table, tombstones are not counted.
+//
+// The complexity is O(1).
+func (table *TableReader[K, V]) Len() int {
+	return table.length
+}
+
+// TombstoneLen returns the number of tombstones in this table.
+//
+// The complexity is O(1).
+func (table *TableReader[K, V]) TombstoneLen() int {
+	return table.deleted
+}
+
+// Get returns value of the element with the key.
+// ok is false if the key is not in the table or has a tombstone, see Deleted.
+// If the bloom filter excludes the key, no data block is read.
+//
+// The complexity is O(log(B)) plus reading one block, B is the number of blocks.
+func (table *TableReader[K, V]) Get(key K) (value V, ok bool, err error) {
+	entry, found, err := table.lookup(key)
+	return entry.value, found && !entry.deleted, err
+}
+
+// Deleted returns true if the table has a tombstone of the key, so older tables must not be searched for it.
+//
+// The complexity is O(log(B)) plus reading one block, B is the number of blocks.
+func (table *TableReader[K, V]) Deleted(key K) (deleted bool, err error) {
+	entry, found, err := table.lookup(key)
+	return found && entry.deleted, err
+}
+
+// lookup returns the record of the key.
+func (table *TableReader[K, V]) lookup(key K) (entry tableEntry[V], found bool, err error) {
+	if table.bloom != nil {
+		data, err := table.keyCodec.Encode(nil, key)
+		if err != nil {
+			return entry, false, err
+		}
+		if !table.bloom.mayContain(data) {
+			return entry, false, nil
+		}
+	}
+	err = table.iterate(table.blockOf(key), &key, func(k K, e tableEntry[V]) bool {
+		if table.comparable(k, key) == 0 {
+			entry, found = e, true
+		}
+		return false
+	})
+	return
+}
+
+// All returns an iterator over key-value pairs from front to back, tombstones are skipped.
+// If the table can't be read, the iteration stops and Err returns the error.
+func (table *TableReader[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		table.setErr(table.iterate(0, nil, values(yield)))
+	}
+}
+
+// Ascend returns an iterator over key-value pairs greater or equal to from, in ascending order.
+// Tombstones are skipped.
+// If the table can't be read, the iteration stops and Err returns the error.
+func (table *TableReader[K, V]) Ascend(from K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		table.setErr(table.iterate(table.blockOf(from), &from, values(yield)))
+	}
+}
+
+// Tombstones returns an iterator over the keys of tombstones in ascending order.
+// If the table can't be read, the iteration stops and Err returns the error.
+func (table *TableReader[K, V]) Tombstones() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		table.setErr(table.iterate(0, nil, func(key K, entry tableEntry[V]) bool {
+			return !entry.deleted || yield(key)
+		}))
+	}
+}
+
+// entries returns an iterator over values and tombstones.
+func (table *TableReader[K, V]) entries() iter.Seq2[K, tableEntry[V]] {
+	return func(yield func(K, tableEntry[V]) bool) {
+		table.setErr(table.iterate(0, nil, yield))
+	}
+}
+
+// values adapts yield of key-value pairs to table entries, skipping tombstones.
+func values[K, V any](yield func(K, V) bool) func(K, tableEntry[V]) bool {
+	return func(key K, entry tableEntry[V]) bool {
+		return entry.deleted || yield(key, entry.value)
+	}
+}
+
+// Err returns the first error met by an iteration.
+func (table *TableReader[K, V]) Err() error {
+	table.errLock.Lock()
+	defer table.errLock.Unlock()
+	return table.err
+}
+
+func (table *TableReader[K, V]) setErr(err error) {
+	if err == nil {
+		return
+	}
+	table.errLock.Lock()
+	defer table.errLock.Unlock()
+	if table.err == nil {
+		table.err = err
+	}
+}
+
+// blockOf returns the last block starting before key, equal keys may continue from it.
+func (table *TableReader[K, V]) blockOf(key K) int {
+	i := sort.Search(len(table.firstKeys), func(i int) bool {
+		return table.comparable(table.firstKeys[i], key) >= 0
+	})
+	return max(i-1, 0)
+}
+
+// iterate calls yield for records from the block start, skipping records less than from if it's not nil.
+func (table *TableReader[K, V]) iterate(start int, from *K, yield func(K, tableEntry[V]) bool) error {
+	for _, block := range table.blocks[start:] {
+		data, err := table.readBlock(block)
+		if err != nil {
+			return err
+		}
+		for len(data) > 0 {
+			kind := data[0]
+			if kind != tableValue && kind != tableTombstone {
+				return fmt.Errorf("%w: unknown record kind %v", ErrInvalidTable, kind)
+			}
+			var key K
+			if key, data, err = decodeRecord(data[1:], table.keyCodec); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidTable, err)
+			}
+			entry := tableEntry[V]{deleted: kind == tableTombstone}
+			if from != nil && table.comparable(key, *from) < 0 {
+				if !entry.deleted {
+					if _, data, err = splitRecord(data); err != nil {
+						return fmt.Errorf("%w: %w", ErrInvalidTable, err)
+					}
+				}
+				continue
+			}
+			from = nil
+			if !entry.deleted {
+				if entry.value, data, err = decodeRecord(data, table.valueCodec); err != nil {
+					return fmt.Errorf("%w: %w", ErrInvalidTable, err)
+				}
+			}
+			if !yield(key, entry) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// readBlock reads a block and verifies its checksum, returns the block without the checksum.
+func (table *TableReader[K, V]) readBlock(block tableBlock) ([]byte, error) {
+	if block.length < crc32.Size || block.length > maxSnapshotRecord {
+		return nil, ErrInvalidTable
+	}
+	data := make([]byte, block.length)
+	if err := readAt(table.r, data, int64(block.offset)); err != nil {
+		return nil, err
+	}
+	data, sum := data[:len(data)-crc32.Size], data[len(data)-crc32.Size:]
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(sum) {
+		return nil, fmt.Errorf("%w: checksum mismatch of block at %v", ErrInvalidTable, block.offset)
+	}
+	return data, nil
+}
+
+func readAt(r io.ReaderAt, buf []byte, offset int64) error {
+	n, err := r.ReadAt(buf, offset)
+	if n == len(buf) {
+		return nil
+	}
+	if err == io.EOF {
+		return ErrInvalidTable
+	}
+	return err
+}
+
+// MergeTables returns an iterator over key-value pairs of list and tables in ascending order,
+// like reading an LSM tree. list is the newest source and tables are ordered from newest to oldest,
+// for equal keys only the pair from the newest source is yielded.
+// A tombstone of the newest source, in a table or in a list created WithTombstones, hides the key of older sources.
+// Errors of tables are reported by their Err.
+//
+// The complexity is O(M*log(S)), M is the number of visited pairs and tombstones and S is the number of sources.
+func MergeTables[K, V any](list SkipList[K, V], tables ...*TableReader[K, V]) iter.Seq2[K, V] {
+	comparable, ok := comparableOf(list)
+	if !ok {
+		if len(tables) == 0 {
+			return list.All()
+		}
+		comparable = tables[0].comparable
+	}
+	seqs := make([]iter.Seq2[K, tableEntry[V]], 0, len(tables)+1)
+	seqs = append(seqs, tableEntriesOf(list))
+	for _, table := range tables {
+		seqs = append(seqs, table.entries())
+	}
+	return func(yield func(K, V) bool) {
+		var last K
+		started := false
+		mergeSeqs(comparable, seqs, func(_ int, key K, entry tableEntry[V]) bool {
+			if started && comparable(last, key) == 0 {
+				return true
+			}
+			last, started = key, true
+			return entry.deleted || yield(key, entry.value)
+		})
+	}
+}
+
+// tableEntriesOf returns an iterator over the values and tombstones of list.
+// The read lock of a list created WithMutex is held during the whole iteration.
+func tableEntriesOf[K, V any](list SkipList[K, V]) iter.Seq2[K, tableEntry[V]] {
+	switch list := list.(type) {
+	case *safeSkipList[K, V]:
+		return func(yield func(K, tableEntry[V]) bool) {
+			list.lock.RLock()
+			defer list.lock.RUnlock()
+			_, _, entries := list.skipListUnSafe.tableEntries()
+			entries(yield)
+		}
+	case *skipListUnSafe[K, V]:
+		return func(yield func(K, tableEntry[V]) bool) {
+			_, _, entries := list.tableEntries()
+			entries(yield)
+		}
+	}
+	return func(yield func(K, tableEntry[V]) bool) {
+		for key, value := range list.All() {
+			if !yield(key, tableEntry[V]{value: value}) {
+				return
+			}
+		}
+	}
+}
+
+// bloomFilter is a bloom filter over encoded keys using double hashing.
+type bloomFilter struct {
+	bits []byte
+	k    byte
+}
+
+func newBloomFilter(n, bitsPerKey int) *bloomFilter {
+	bits := max(n*bitsPerKey, 64)
+	// k = ln(2) * bits per key minimizes false positives.
+	k := min(max(int(float64(bitsPerKey)*math.Ln2), 1), 30)
+	return &bloomFilter{bits: make([]byte, (bits+7)/8), k: byte(k)}
+}
+
+func (f *bloomFilter) add(key []byte) {
+	h, delta := bloomHash(key)
+	bits := uint64(len(f.bits)) * 8
+	for i := byte(0); i < f.k; i++ {
+		pos := h % bits
+		f.bits[pos/8] |= 1 << (pos % 8)
+		h += delta
+	}
+}
+
+func (f *bloomFilter) mayContain(key []byte) bool {
+	h, delta := bloomHash(key)
+	bits := uint64(len(f.bits)) * 8
+	for i := byte(0); i < f.k; i++ {
+		pos := h % bits
+		if f.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+		h += delta
+	}
+	return true
+}
+
+// bloomHash returns the first hash and the step of double hashing.
+func bloomHash(key []byte) (h, delta uint64) {
+	hash := fnv.New64a()
+	hash.Write(key)
+	h = hash.Sum64()
+	return h, h>>33 | h<<31
+}
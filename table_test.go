@@ -0,0 +1,265 @@
+package skiplist
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func newTableList(options ...Option) SkipList[int, string] {
+	options = append(options, WithCodec[int, string](NumberCodec[int]{}, BytesCodec[string]{}))
+	return New[int, string](NumberComparator[int], options...)
+}
+
+func openTable(a *assert.Assertions, list SkipList[int, string], options ...TableOption) *TableReader[int, string] {
+	var buf bytes.Buffer
+	n, err := list.Flush(&buf, options...)
+	a.NoError(err)
+	a.Equal(int64(buf.Len()), n)
+	table, err := OpenTable[int, string](bytes.NewReader(buf.Bytes()), n, NumberComparator[int], NumberCodec[int]{}, BytesCodec[string]{})
+	a.NoError(err)
+	return table
+}
+
+func TestTable(t *testing.T) {
+	a := assert.New(t)
+	list := newTableList(WithMutex())
+	for _, i := range rand.Perm(1000) {
+		list.Set(i*2, string(rune('a'+i%26)))
+	}
+
+	for _, options := range [][]TableOption{
+		nil,
+		{WithBlockSize(64)},
+		{WithBlockSize(1), WithBloomFilter(10)},
+	} {
+		table := openTable(a, list, options...)
+		a.Equal(list.Len(), table.Len())
+		for i := -1; i <= 2000; i++ {
+			value, ok, err := table.Get(i)
+			a.NoError(err)
+			expected, found := list.GetValue(i)
+			a.Equal(found, ok, i)
+			a.Equal(expected, value)
+		}
+
+		var keys []int
+		for k, v := range table.All() {
+			keys = append(keys, k)
+			a.Equal(list.MustGetValue(k), v)
+		}
+		a.Equal(list.Keys(), keys)
+
+		keys = keys[:0]
+		for k := range table.Ascend(501) {
+			if k > 600 {
+				break
+			}
+			keys = append(keys, k)
+		}
+		a.Len(keys, 50)
+		a.Equal(502, keys[0])
+		a.NoError(table.Err())
+	}
+
+	empty := openTable(a, newTableList())
+	a.Equal(0, empty.Len())
+	_, ok, err := empty.Get(1)
+	a.NoError(err)
+	a.False(ok)
+
+	_, err = New[int, string](NumberComparator[int]).Flush(&bytes.Buffer{})
+	a.ErrorIs(err, ErrNoCodec)
+}
+
+func TestTableDuplicates(t *testing.T) {
+	a := assert.New(t)
+	list := newTableList(WithDuplicates())
+	for i := 0; i < 100; i++ {
+		list.Set(i/10, string(rune('a'+i%10)))
+	}
+	table := openTable(a, list, WithBlockSize(16))
+	value, ok, err := table.Get(5)
+	a.NoError(err)
+	a.True(ok)
+	a.Equal("a", value)
+	count := 0
+	for k := range table.Ascend(5) {
+		if k != 5 {
+			break
+		}
+		count++
+	}
+	a.Equal(10, count)
+}
+
+func TestTableCorrupted(t *testing.T) {
+	a := assert.New(t)
+	list := newTableList()
+	for i := 0; i < 100; i++ {
+		list.Set(i, "value")
+	}
+	var buf bytes.Buffer
+	_, err := list.Flush(&buf, WithBlockSize(64))
+	a.NoError(err)
+	data := buf.Bytes()
+
+	open := func(data []byte) (*TableReader[int, string], error) {
+		return OpenTable[int, string](bytes.NewReader(data), int64(len(data)), NumberComparator[int], NumberCodec[int]{}, BytesCodec[string]{})
+	}
+	_, err = open(data[:len(data)-1])
+	a.ErrorIs(err, ErrInvalidTable)
+	_, err = open(data[:10])
+	a.ErrorIs(err, ErrInvalidTable)
+
+	corrupted := append([]byte{}, data...)
+	corrupted[3] ^= 0xff
+	table, err := open(corrupted)
+	a.NoError(err)
+	_, _, err = table.Get(0)
+	a.ErrorIs(err, ErrInvalidTable)
+	for range table.All() {
+	}
+	a.ErrorIs(table.Err(), ErrInvalidTable)
+}
+
+func TestMergeTables(t *testing.T) {
+	a := assert.New(t)
+	oldest := newTableList()
+	middle := newTableList()
+	live := newTableList(WithMutex())
+	expected := map[int]string{}
+	for i := 0; i < 300; i++ {
+		oldest.Set(i, "oldest")
+		expected[i] = "oldest"
+	}
+	for i := 0; i < 300; i += 2 {
+		middle.Set(i, "middle")
+		expected[i] = "middle"
+	}
+	for i := 0; i < 400; i += 3 {
+		live.Set(i, "live")
+		expected[i] = "live"
+	}
+
+	tables := []*TableReader[int, string]{openTable(a, middle, WithBlockSize(32)), openTable(a, oldest, WithBloomFilter(8))}
+	prev := -1
+	for k, v := range MergeTables(live, tables...) {
+		a.Greater(k, prev)
+		prev = k
+		a.Equal(expected[k], v, k)
+		delete(expected, k)
+	}
+	a.Empty(expected)
+
+	count := 0
+	for range MergeTables(live, tables...) {
+		if count++; count == 10 {
+			break
+		}
+	}
+	a.Equal(10, count)
+	live.Set(1000, "unlocked")
+
+	// without WithTombstones, a key removed from the list is yielded from an older table.
+	live.Remove(0)
+	for k, v := range MergeTables(live, tables...) {
+		a.Equal(0, k)
+		a.Equal("middle", v)
+		break
+	}
+}
+
+func TestTableTombstones(t *testing.T) {
+	a := assert.New(t)
+	list := newTableList(WithTombstones())
+	for i := 0; i < 100; i++ {
+		list.Set(i, "old")
+	}
+	older := openTable(a, list, WithBlockSize(32))
+	a.Equal(100, older.Len())
+	a.Equal(0, older.TombstoneLen())
+
+	list.Remove(10)
+	list.RemoveRange(20, 29)
+	list.Set(25, "new")
+	list.Set(100, "new")
+	newer := openTable(a, list, WithBlockSize(32), WithBloomFilter(8))
+	a.Equal(91, newer.Len())
+	a.Equal(10, newer.TombstoneLen())
+	var tombstones []int
+	for k := range newer.Tombstones() {
+		tombstones = append(tombstones, k)
+	}
+	a.Equal([]int{10, 20, 21, 22, 23, 24, 26, 27, 28, 29}, tombstones)
+	a.NoError(newer.Err())
+
+	_, ok, err := newer.Get(10)
+	a.NoError(err)
+	a.False(ok)
+	deleted, err := newer.Deleted(10)
+	a.NoError(err)
+	a.True(deleted)
+	deleted, err = newer.Deleted(25)
+	a.NoError(err)
+	a.False(deleted)
+	for k := range newer.Ascend(20) {
+		a.Equal(25, k)
+		break
+	}
+
+	// the key stays deleted after the list is cleared, the newer table hides it in the older one.
+	live := newTableList(WithTombstones())
+	live.Set(50, "live")
+	live.Remove(60)
+	expected := map[int]string{}
+	for i := 0; i <= 100; i++ {
+		switch {
+		case i == 10 || i == 60 || i >= 20 && i < 30 && i != 25:
+		case i == 50:
+			expected[i] = "live"
+		case i == 25 || i == 100:
+			expected[i] = "new"
+		default:
+			expected[i] = "old"
+		}
+	}
+	actual := map[int]string{}
+	for k, v := range MergeTables(live, newer, older) {
+		actual[k] = v
+	}
+	a.Equal(expected, actual)
+	a.NoError(newer.Err())
+	a.NoError(older.Err())
+
+	// setting the key again removes its tombstone.
+	list.Set(10, "again")
+	table := openTable(a, list)
+	a.Equal(9, table.TombstoneLen())
+	v, ok, err := table.Get(10)
+	a.NoError(err)
+	a.True(ok)
+	a.Equal("again", v)
+}
+
+func TestTombstonesBatch(t *testing.T) {
+	a := assert.New(t)
+	list := newTableList(WithTombstones())
+	list.Set(1, "one")
+	list.Remove(2)
+	err := list.Apply(NewBatch(func(op BatchOp[int, string], old string, exists bool) error {
+		if op.Key == 3 {
+			return assert.AnError
+		}
+		return nil
+	}).Remove(1).Set(2, "two").Set(3, "three"))
+	a.ErrorIs(err, assert.AnError)
+	table := openTable(a, list)
+	a.Equal(1, table.Len())
+	var tombstones []int
+	for k := range table.Tombstones() {
+		tombstones = append(tombstones, k)
+	}
+	a.Equal([]int{2}, tombstones)
+}
@@ -0,0 +1,104 @@
+package skiplist
+
+import "iter"
+
+// tombstones is the set of keys removed from a list created WithTombstones.
+// Flush writes them as deletion markers, so MergeTables doesn't yield the keys from older tables.
+type tombstones[K any] struct {
+	towers[K, struct{}]
+}
+
+func newTombstones[K any](comparable Comparable[K], option *Options) *tombstones[K] {
+	return &tombstones[K]{newTowers[K, struct{}](comparable, option)}
+}
+
+// has returns true if key is removed.
+func (t *tombstones[K]) has(key K) bool {
+	node := t.find(key, false)
+	return node != nil && t.comparable(node.key, key) == 0
+}
+
+func (t *tombstones[K]) add(key K) {
+	prevs := t.search(key)
+	if node := prevs[0].next[0]; node != nil && t.comparable(node.key, key) == 0 {
+		return
+	}
+	t.link(prevs, t.newTower(key))
+}
+
+func (t *tombstones[K]) remove(key K) {
+	prevs := t.search(key)
+	if node := prevs[0].next[0]; node != nil && t.comparable(node.key, key) == 0 {
+		t.unlink(prevs, node)
+	}
+}
+
+// tombstone records that key is removed, if the list keeps tombstones.
+func (list *skipListUnSafe[K, V]) tombstone(key K) {
+	if list.tombstones != nil {
+		list.tombstones.add(key)
+	}
+}
+
+// tombstoned returns true if the list has a tombstone of key.
+func (list *skipListUnSafe[K, V]) tombstoned(key K) bool {
+	return list.tombstones != nil && list.tombstones.has(key)
+}
+
+// tableEntry is a value or a deletion marker of a sorted table.
+type tableEntry[V any] struct {
+	value   V
+	deleted bool
+}
+
+// tableEntries returns the elements which haven't expired at the time of the call and the tombstones
+// in key order, and their counts. Expired elements are written as tombstones if the list keeps them.
+// The entries are fixed by the time of the call, like liveElements.
+func (list *skipListUnSafe[K, V]) tableEntries() (count, deleted int, entries iter.Seq2[K, tableEntry[V]]) {
+	live := func(elem *Element[K, V]) bool { return true }
+	if list.expiry != nil {
+		now := list.expiry.now()
+		live = func(elem *Element[K, V]) bool { return elem.expireAt == 0 || elem.expireAt > now }
+	}
+	walk := func(yield func(K, tableEntry[V]) bool) {
+		elem := list.next[0]
+		var node *tower[K, struct{}]
+		if list.tombstones != nil {
+			node = list.tombstones.head.next[0]
+		}
+		for elem != nil || node != nil {
+			if node != nil && (elem == nil || list.comparable(node.key, elem.key) < 0) {
+				if !yield(node.key, tableEntry[V]{deleted: true}) {
+					return
+				}
+				node = node.next[0]
+				continue
+			}
+			if node != nil && list.comparable(node.key, elem.key) == 0 {
+				// the element was set again after it was removed.
+				node = node.next[0]
+			}
+			var ok bool
+			switch {
+			case live(elem):
+				ok = yield(elem.key, tableEntry[V]{value: elem.Value})
+			case list.tombstones != nil:
+				ok = yield(elem.key, tableEntry[V]{deleted: true})
+			default:
+				ok = true
+			}
+			if !ok {
+				return
+			}
+			elem = elem.next[0]
+		}
+	}
+	for _, entry := range walk {
+		if entry.deleted {
+			deleted++
+		} else {
+			count++
+		}
+	}
+	return count, deleted, walk
+}
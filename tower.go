@@ -0,0 +1,146 @@
+package skiplist
+
+import (
+	"math/rand"
+	"time"
+)
+
+// levelGenerator draws random tower heights from a probability table, see probabilityTable.
+type levelGenerator struct {
+	probTable []float64
+	rand      *rand.Rand // nil uses the global source, which is safe for concurrent use.
+}
+
+func newLevelGenerator(probability float64, maxLevel int, source *rand.Rand) levelGenerator {
+	return levelGenerator{
+		probTable: probabilityTable(probability, maxLevel),
+		rand:      source,
+	}
+}
+
+// newRand returns a rand seeded by the current time, it's not safe for concurrent use.
+func newRand() *rand.Rand {
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+func (levels *levelGenerator) randLevel() (level int) {
+	var r float64
+	if levels.rand != nil {
+		r = float64(levels.rand.Int63()) / (1 << 63)
+	} else {
+		r = float64(rand.Int63()) / (1 << 63)
+	}
+	for level = 1; level < len(levels.probTable) && r < levels.probTable[level]; level++ {
+	}
+	return
+}
+
+// tower is a node of towers, data holds whatever the list keeps per node.
+type tower[K, T any] struct {
+	key  K
+	next []*tower[K, T]
+	data T
+}
+
+// towers is a plain skip list without spans, shared by the lists keeping their own data in nodes,
+// like Augmented and IntervalList. It's not safe for concurrent use.
+type towers[K, T any] struct {
+	head       *tower[K, T]
+	comparable Comparable[K]
+	levels     levelGenerator
+	prevs      []*tower[K, T]
+}
+
+func newTowers[K, T any](comparable Comparable[K], option *Options) towers[K, T] {
+	return towers[K, T]{
+		head:       &tower[K, T]{next: make([]*tower[K, T], option.maxLevel)},
+		comparable: comparable,
+		levels:     newLevelGenerator(option.probability, option.maxLevel, newRand()),
+		prevs:      make([]*tower[K, T], option.maxLevel),
+	}
+}
+
+func (t *towers[K, T]) maxLevel() int {
+	return len(t.head.next)
+}
+
+// search returns the last nodes before key on each level.
+// The returned slice is reused by the next search.
+func (t *towers[K, T]) search(key K) []*tower[K, T] {
+	prev := t.head
+	for i := len(t.head.next) - 1; i >= 0; i-- {
+		for next := prev.next[i]; next != nil && t.comparable(next.key, key) < 0; next = prev.next[i] {
+			prev = next
+		}
+		t.prevs[i] = prev
+	}
+	return t.prevs
+}
+
+// find returns the first node greater or equal to key, or greater than key if exclusive is true.
+func (t *towers[K, T]) find(key K, exclusive bool) *tower[K, T] {
+	return t.before(key, exclusive).next[0]
+}
+
+// last returns the last node less than key, or less or equal to key if inclusive is true.
+// Returns nil if there is no such node.
+func (t *towers[K, T]) last(key K, inclusive bool) *tower[K, T] {
+	if prev := t.before(key, inclusive); prev != t.head {
+		return prev
+	}
+	return nil
+}
+
+// back returns the last node, or nil if there are no nodes.
+func (t *towers[K, T]) back() *tower[K, T] {
+	prev := t.head
+	for i := len(t.head.next) - 1; i >= 0; i-- {
+		for prev.next[i] != nil {
+			prev = prev.next[i]
+		}
+	}
+	if prev == t.head {
+		return nil
+	}
+	return prev
+}
+
+// before returns the last node less than key, or less or equal to key if inclusive is true, or head.
+func (t *towers[K, T]) before(key K, inclusive bool) *tower[K, T] {
+	prev := t.head
+	for i := len(t.head.next) - 1; i >= 0; i-- {
+		for next := prev.next[i]; next != nil; next = prev.next[i] {
+			c := t.comparable(next.key, key)
+			if c > 0 || c == 0 && !inclusive {
+				break
+			}
+			prev = next
+		}
+	}
+	return prev
+}
+
+// newTower returns an unlinked node of key with a random level.
+func (t *towers[K, T]) newTower(key K) *tower[K, T] {
+	return &tower[K, T]{key: key, next: make([]*tower[K, T], t.levels.randLevel())}
+}
+
+// link inserts node after prevs, as returned by search.
+func (t *towers[K, T]) link(prevs []*tower[K, T], node *tower[K, T]) {
+	for i := range node.next {
+		node.next[i] = prevs[i].next[i]
+		prevs[i].next[i] = node
+	}
+}
+
+// clear removes all nodes.
+func (t *towers[K, T]) clear() {
+	clear(t.head.next)
+}
+
+// unlink removes node after prevs, as returned by search.
+func (t *towers[K, T]) unlink(prevs []*tower[K, T], node *tower[K, T]) {
+	for i := range node.next {
+		prevs[i].next[i] = node.next[i]
+	}
+}
@@ -3,26 +3,51 @@
 
 package skiplist
 
-//func TestCompareTypes(t *testing.T) {
-//	a := assert.New(t)
-//	cases := []struct {
-//		kt       Comparable[int]
-//		lhs, rhs interface{}
-//		result   int
-//	}{
-//		{NumberComparator[int], 0, 0, 0},
-//		{NumberComparator[int], 2, 0, 1},
-//		{NumberComparator[int], -1, 1, -1},
-//		{NumberComparator[byte], 9, 2, 1},
-//		{NumberComparator[float64], 1.2, 1.20001, -1},
-//		{BytesComparator[string], "foo", "bar", 1},
-//		{BytesComparator[string], "001", "101", -1},
-//		{BytesComparator[string], "equals", "equals", 0},
-//		{BytesComparator[[]byte], []byte("abcdefghijk"), []byte("abcdefghij"), 1},
-//	}
-//
-//	for i, c := range cases {
-//		a.Use(&i, &c)
-//		a.Equal(c.result, c.kt(c.lhs, c.rhs))
-//	}
-//}
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCompareTypes(t *testing.T) {
+	a := assert.New(t)
+	cases := []struct {
+		result   int
+		expected int
+	}{
+		{NumberComparator[int](0, 0), 0},
+		{NumberComparator[int](2, 0), 1},
+		{NumberComparator[int](-1, 1), -1},
+		{NumberComparator[byte](9, 2), 1},
+		{NumberComparator[float64](1.2, 1.20001), -1},
+		{BytesComparator[string]("foo", "bar"), 1},
+		{BytesComparator[string]("001", "101"), -1},
+		{BytesComparator[string]("equals", "equals"), 0},
+		{BytesComparator[string]("", ""), 0},
+		{BytesComparator[string]("", "a"), -1},
+		{BytesComparator[string]("a", "a\x00"), -1},
+		{BytesComparator[string]("abcdefgh", "abcdefgh\x00"), -1},
+		{BytesComparator[string]("abcdefghijk", "abcdefghijz"), -1},
+		{BytesComparator[string]("abcdefghijz", "abcdefghijk"), 1},
+		{BytesComparator[string]("abcdefghijklmnop", "abcdefghijklmnop"), 0},
+		{BytesComparator[string]("abcdefghi", "abcdefgj"), -1},
+		{BytesComparator[[]byte]([]byte("abcdefghijk"), []byte("abcdefghij")), 1},
+		{BytesComparator[[]byte]([]byte("abcdefghij"), []byte("abcdefghijk")), -1},
+		{BytesComparator[[]byte]([]byte{0xff, 0, 0, 0, 0, 0, 0, 0, 1}, []byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0xff}), -1},
+	}
+
+	for i, c := range cases {
+		a.Equal(c.expected, c.result, "case %v", i)
+	}
+}
+
+func TestBytesComparatorLongKeys(t *testing.T) {
+	a := assert.New(t)
+	list := New[string, int](BytesComparator[string])
+	list.Set("abcdefghijz", 2)
+	list.Set("abcdefghijk", 1)
+	list.Set("abcdefghij", 0)
+	a.Equal(3, list.Len())
+	a.Equal([]string{"abcdefghij", "abcdefghijk", "abcdefghijz"}, list.Keys())
+	a.Equal(1, list.MustGetValue("abcdefghijk"))
+	a.Equal(2, list.MustGetValue("abcdefghijz"))
+}
@@ -0,0 +1,235 @@
+package skiplist
+
+import (
+	"errors"
+	"iter"
+	"sort"
+	"sync"
+)
+
+// ErrStaleVersion is returned when a write uses a version older than the latest version of the key.
+var ErrStaleVersion = errors.New("skiplist: version is older than the latest version of the key")
+
+// Versioned is a goroutine-safe multi-version skip list.
+// Every Set and Remove records a new version of the key instead of overwriting it,
+// so reads can see the list as it was at any version with AsOf.
+// Old versions are kept until GC drops them.
+type Versioned[K, V any] struct {
+	list    *skipListUnSafe[K, []versionedValue[V]]
+	lock    sync.RWMutex
+	version uint64
+}
+
+// versionedValue is a value of a key since version, or a removal if deleted is true.
+type versionedValue[V any] struct {
+	version uint64
+	value   V
+	deleted bool
+}
+
+// NewVersioned creates a new versioned skip list with comparable to compare keys.
+// WithMutex, WithLockFree and WithDuplicates are ignored, Versioned always has its own lock.
+// WithCapacity, TTL options and WithTombstones are ignored as well: versions are dropped only by GC,
+// evicting or expiring a key would drop all of its versions at once.
+func NewVersioned[K, V any](comparable Comparable[K], options ...Option) *Versioned[K, V] {
+	option := newOptions(options)
+	option.duplicates = false
+	option.capacity, option.onEvict = 0, nil
+	option.expiry, option.onExpire = false, nil
+	option.tombstones = false
+	list := newSkipListUnSafe[K, []versionedValue[V]](comparable, option)
+	return &Versioned[K, V]{list: list}
+}
+
+// Version returns the latest version of the list.
+func (v *Versioned[K, V]) Version() uint64 {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	return v.version
+}
+
+// Len returns the number of keys having any version, including removed keys not yet collected by GC.
+func (v *Versioned[K, V]) Len() int {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	return v.list.Len()
+}
+
+// Set sets value for the key at the next version of the list.
+// Returns the version of the write.
+//
+// The complexity is O(log(N)).
+func (v *Versioned[K, V]) Set(key K, value V) (version uint64) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.version++
+	_ = v.write(key, versionedValue[V]{version: v.version, value: value})
+	return v.version
+}
+
+// SetAt sets value for the key at version.
+// Returns ErrStaleVersion if the key already has a newer version.
+//
+// The complexity is O(log(N)).
+func (v *Versioned[K, V]) SetAt(key K, value V, version uint64) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return v.write(key, versionedValue[V]{version: version, value: value})
+}
+
+// Remove removes the key at the next version of the list.
+// Returns the version of the removal.
+//
+// The complexity is O(log(N)).
+func (v *Versioned[K, V]) Remove(key K) (version uint64) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.version++
+	_ = v.write(key, versionedValue[V]{version: v.version, deleted: true})
+	return v.version
+}
+
+// RemoveAt removes the key at version.
+// Returns ErrStaleVersion if the key already has a newer version.
+//
+// The complexity is O(log(N)).
+func (v *Versioned[K, V]) RemoveAt(key K, version uint64) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return v.write(key, versionedValue[V]{version: version, deleted: true})
+}
+
+// GetValue returns the latest value of the key.
+//
+// The complexity is O(log(N)).
+func (v *Versioned[K, V]) GetValue(key K) (val V, ok bool) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	return v.getValue(key, v.version)
+}
+
+// AsOf returns a read-only view of the list at version.
+// Writes with a newer version are not visible through the view,
+// but versions dropped by GC are no longer visible either.
+func (v *Versioned[K, V]) AsOf(version uint64) *VersionedView[K, V] {
+	return &VersionedView[K, V]{versioned: v, version: version}
+}
+
+// GC drops versions that are not visible at watermark or any later version.
+// For each key, the latest version not newer than watermark is kept, unless it is a removal.
+// Returns the number of dropped versions.
+//
+// The complexity is O(N+M), M is the number of dropped versions.
+func (v *Versioned[K, V]) GC(watermark uint64) (dropped int) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	for elem := v.list.Front(); elem != nil; {
+		next := elem.Next()
+		versions := elem.Value
+		i := visibleVersion(versions, watermark)
+		if i >= 0 && versions[i].deleted {
+			// nothing before the removal can be seen anymore.
+			i++
+		}
+		if i > 0 {
+			dropped += i
+			elem.Value = append(versions[:0:0], versions[i:]...)
+		}
+		if len(elem.Value) == 0 {
+			v.list.RemoveElement(elem)
+		}
+		elem = next
+	}
+	return
+}
+
+// write appends version to the key, the lock must be held.
+func (v *Versioned[K, V]) write(key K, version versionedValue[V]) error {
+	elem, _ := v.list.GetOrSet(key, nil)
+	versions := elem.Value
+	if n := len(versions); n > 0 {
+		switch last := versions[n-1].version; {
+		case last > version.version:
+			return ErrStaleVersion
+		case last == version.version:
+			versions[n-1] = version
+			return nil
+		}
+	}
+	elem.Value = append(versions, version)
+	v.version = max(v.version, version.version)
+	return nil
+}
+
+// getValue returns the value of the key visible at version, the lock must be held.
+func (v *Versioned[K, V]) getValue(key K, version uint64) (val V, ok bool) {
+	elem := v.list.Get(key)
+	if elem == nil {
+		return
+	}
+	return visibleValue(elem.Value, version)
+}
+
+// visibleVersion returns the index of the latest version not newer than version, or -1.
+func visibleVersion[V any](versions []versionedValue[V], version uint64) int {
+	return sort.Search(len(versions), func(i int) bool {
+		return versions[i].version > version
+	}) - 1
+}
+
+func visibleValue[V any](versions []versionedValue[V], version uint64) (val V, ok bool) {
+	i := visibleVersion(versions, version)
+	if i < 0 || versions[i].deleted {
+		return
+	}
+	return versions[i].value, true
+}
+
+// VersionedView is a read-only view of a Versioned list at a version.
+type VersionedView[K, V any] struct {
+	versioned *Versioned[K, V]
+	version   uint64
+}
+
+// Version returns the version of the view.
+func (view *VersionedView[K, V]) Version() uint64 {
+	return view.version
+}
+
+// GetValue returns value of the key visible at the version of the view.
+//
+// The complexity is O(log(N)).
+func (view *VersionedView[K, V]) GetValue(key K) (val V, ok bool) {
+	view.versioned.lock.RLock()
+	defer view.versioned.lock.RUnlock()
+	return view.versioned.getValue(key, view.version)
+}
+
+// All returns an iterator over key-value pairs visible at the version of the view, from front to back.
+// The read lock is held during the whole iteration, so the loop body must not modify the list.
+func (view *VersionedView[K, V]) All() iter.Seq2[K, V] {
+	return view.iterate(func(list *skipListUnSafe[K, []versionedValue[V]]) *Element[K, []versionedValue[V]] {
+		return list.Front()
+	})
+}
+
+// Ascend returns an iterator over key-value pairs visible at the version of the view,
+// greater or equal to from, in ascending order.
+// The read lock is held during the whole iteration, so the loop body must not modify the list.
+func (view *VersionedView[K, V]) Ascend(from K) iter.Seq2[K, V] {
+	return view.iterate(func(list *skipListUnSafe[K, []versionedValue[V]]) *Element[K, []versionedValue[V]] {
+		return list.Find(from)
+	})
+}
+
+func (view *VersionedView[K, V]) iterate(start func(list *skipListUnSafe[K, []versionedValue[V]]) *Element[K, []versionedValue[V]]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		view.versioned.lock.RLock()
+		defer view.versioned.lock.RUnlock()
+		for elem := start(view.versioned.list); elem != nil; elem = elem.Next() {
+			if val, ok := visibleValue(elem.Value, view.version); ok && !yield(elem.key, val) {
+				return
+			}
+		}
+	}
+}
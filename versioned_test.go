@@ -0,0 +1,75 @@
+package skiplist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestVersioned(t *testing.T) {
+	a := assert.New(t)
+	list := NewVersioned[string, int](BytesComparator[string])
+	v1 := list.Set("a", 1)
+	v2 := list.Set("b", 2)
+	v3 := list.Set("a", 3)
+	v4 := list.Remove("b")
+	a.Equal([]uint64{1, 2, 3, 4}, []uint64{v1, v2, v3, v4})
+	a.Equal(uint64(4), list.Version())
+
+	val, ok := list.GetValue("a")
+	a.True(ok)
+	a.Equal(3, val)
+	_, ok = list.GetValue("b")
+	a.False(ok)
+
+	_, ok = list.AsOf(0).GetValue("a")
+	a.False(ok)
+	val, _ = list.AsOf(v1).GetValue("a")
+	a.Equal(1, val)
+	val, _ = list.AsOf(v3).GetValue("b")
+	a.Equal(2, val)
+	a.Equal(map[string]int{"a": 1, "b": 2}, maps.Collect(list.AsOf(v2).All()))
+	a.Equal(map[string]int{"a": 3}, maps.Collect(list.AsOf(v4).All()))
+	a.Equal(map[string]int{"b": 2}, maps.Collect(list.AsOf(v2).Ascend("b")))
+
+	a.NoError(list.SetAt("c", 10, 10))
+	a.ErrorIs(list.SetAt("c", 9, 9), ErrStaleVersion)
+	a.ErrorIs(list.RemoveAt("c", 9), ErrStaleVersion)
+	a.NoError(list.SetAt("c", 11, 10))
+	val, _ = list.GetValue("c")
+	a.Equal(11, val)
+	a.Equal(uint64(10), list.Version())
+	a.Equal(uint64(11), list.Set("d", 0))
+	a.NoError(list.RemoveAt("d", 12))
+	a.Equal(4, list.Len())
+
+	// a: 1@1 3@3, b: 2@2 deleted@4, c: 11@10, d: 0@11 deleted@12
+	a.Equal(1, list.GC(3))
+	val, _ = list.AsOf(3).GetValue("a")
+	a.Equal(3, val)
+	val, _ = list.AsOf(3).GetValue("b")
+	a.Equal(2, val)
+	a.Equal(2, list.GC(4))
+	a.Equal(3, list.Len())
+	a.Equal(2, list.GC(100))
+	a.Equal(2, list.Len())
+	a.Equal(map[string]int{"a": 3, "c": 11}, maps.Collect(list.AsOf(100).All()))
+}
+
+func TestVersionedIgnoredOptions(t *testing.T) {
+	a := assert.New(t)
+	clock := &fakeClock{}
+	list := NewVersioned[int, string](NumberComparator[int],
+		WithCapacity(1, EvictSmallest), WithOnEvict(func(int, string) {}),
+		WithExpiry(clock.Now, func(int, string) {}), WithTombstones(), WithDuplicates())
+	list.Set(2, "two")
+	list.Set(1, "one")
+	list.Set(1, "uno")
+	clock.Advance(time.Hour)
+	a.Equal(2, list.Len())
+	val, ok := list.GetValue(1)
+	a.True(ok)
+	a.Equal("uno", val)
+	a.Equal(map[int]string{1: "uno", 2: "two"}, maps.Collect(list.AsOf(list.Version()).All()))
+}